@@ -2,14 +2,18 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"sort"
 
 	"github.com/codingbrain/clix.go/exts/bind"
+	"github.com/codingbrain/clix.go/exts/complete"
 	"github.com/codingbrain/clix.go/exts/help"
 	"github.com/codingbrain/clix.go/flag"
 	"github.com/codingbrain/clix.go/gen"
+	"github.com/codingbrain/clix.go/gen/golang"
+	"github.com/codingbrain/clix.go/term"
 
-	_ "github.com/codingbrain/clix.go/gen/golang"
+	_ "github.com/codingbrain/clix.go/gen/completion"
 )
 
 type genCmd struct {
@@ -17,6 +21,7 @@ type genCmd struct {
 	Output  string
 	Backend string
 	Params  map[string]interface{} `n:"define"`
+	Strict  bool
 }
 
 func (c *genCmd) Execute([]string) error {
@@ -32,10 +37,15 @@ func (c *genCmd) Execute([]string) error {
 		return fmt.Errorf("backend not found: %s", c.Backend)
 	}
 
-	def, err := flag.DecodeCliDefFile(c.DefFile)
+	def, err := flag.NewDefDecoder().StrictKeys(c.Strict).DecodeCliDefFile(c.DefFile)
 	if err != nil {
 		return err
 	}
+	if def.Cli != nil {
+		for _, warning := range def.Cli.Warnings {
+			term.Warnln("WARNING: " + warning)
+		}
+	}
 
 	w, err := gen.NewFileWriter(c.Output)
 	if err != nil {
@@ -56,6 +66,14 @@ func (c *backendsCmd) Execute([]string) error {
 	return nil
 }
 
+type schemaCmd struct {
+}
+
+func (c *schemaCmd) Execute([]string) error {
+	_, err := os.Stdout.Write(flag.JSONSchemaBytes())
+	return err
+}
+
 func backendNames() []string {
 	names := make([]string, 0, len(gen.BackendFactories))
 	for name := range gen.BackendFactories {
@@ -90,7 +108,7 @@ func main() {
 							Name:    "backend",
 							Alias:   []string{"b"},
 							Desc:    "Specify the backend, use backends to list all backends",
-							Default: backendNames()[0],
+							Default: golang.BackendName,
 						},
 						&flag.Option{
 							Name:  "define",
@@ -98,20 +116,30 @@ func main() {
 							Desc:  "Define backend specific parameters",
 							Type:  "dict",
 						},
+						&flag.Option{
+							Name: "strict",
+							Desc: "Reject unknown keys in definition file",
+							Type: "bool",
+						},
 					},
 				},
 				&flag.Command{
 					Name: "backends",
 					Desc: "List supported backends",
 				},
+				&flag.Command{
+					Name: "schema",
+					Desc: "Print JSON Schema of definition file",
+				},
 			},
 		},
 	}
 	cli.Normalize()
-	cli.Use(
-		bind.NewExt().
+	cli.Use(complete.NewExt()).
+		Use(bind.NewExt().
 			Bind(&genCmd{}, "gen").
-			Bind(&backendsCmd{}, "backends")).
+			Bind(&backendsCmd{}, "backends").
+			Bind(&schemaCmd{}, "schema")).
 		Use(help.NewExt()).
 		Parse().Exec()
 }
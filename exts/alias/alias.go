@@ -0,0 +1,217 @@
+package alias
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codingbrain/clix.go/flag"
+	"gopkg.in/yaml.v2"
+)
+
+// HelpTitle is the title of the section listing aliases in help
+var HelpTitle = "Aliases"
+
+// RecursionError is the error when an alias is expanded to itself
+type RecursionError struct {
+	// Chain lists the aliases being expanded, ending with the recursive one
+	Chain []string
+}
+
+func (e *RecursionError) Error() string {
+	return "recursive alias: " + strings.Join(e.Chain, " -> ")
+}
+
+// AliasExt defines the alias extension which expands user-defined aliases
+// of commands, and must be hooked up to
+// - EvtStartCmd
+// - EvtResolveCmd
+//
+// An alias is only expanded when the name is not a subcommand. The expansion
+// is split into arguments like a POSIX shell (see flag.SplitArgs) and parsed
+// in place of the alias, so it can contain options and refer to other aliases.
+// An alias of a nested subcommand is named with the path of parent commands
+// (excluding the root), e.g. "remote rm" is an alias under "remote".
+// Aliases are loaded in order (later ones win):
+// - the aliases added by Add/AddMap
+// - the files specified by Load, which map alias names to expansions
+type AliasExt struct {
+	// Aliases maps alias names to expansions
+	Aliases map[string]string
+	// Files are YAML/JSON files to load aliases, missing files are ignored
+	Files []string
+
+	loaded    map[string]string
+	expanding []aliasExpansion
+}
+
+// aliasExpansion is an alias being expanded, until the args pushed back
+// before its expanded args are reached
+type aliasExpansion struct {
+	name string
+	base int
+}
+
+// NewExt creates alias extension
+func NewExt() *AliasExt {
+	return &AliasExt{Aliases: make(map[string]string)}
+}
+
+// Add defines an alias
+func (x *AliasExt) Add(name, expansion string) *AliasExt {
+	x.Aliases[name] = expansion
+	return x
+}
+
+// AddMap defines aliases from a map
+func (x *AliasExt) AddMap(aliases map[string]string) *AliasExt {
+	for name, expansion := range aliases {
+		x.Aliases[name] = expansion
+	}
+	return x
+}
+
+// Load specifies the files to load aliases
+func (x *AliasExt) Load(files ...string) *AliasExt {
+	x.Files = append(x.Files, files...)
+	return x
+}
+
+// HandleParseEvent implements parse extension
+func (x *AliasExt) HandleParseEvent(event string, ctx *flag.ParseContext) {
+	switch event {
+	case flag.EvtStartCmd:
+		if len(ctx.CmdStack()) == 1 {
+			x.expanding = nil
+			if err := x.loadAll(); err != nil {
+				ctx.Abort(err)
+			}
+		}
+	case flag.EvtResolveCmd:
+		name := aliasName(ctx.CmdStack(), ctx.Name)
+		expansion, exists := x.loaded[name]
+		if !exists {
+			return
+		}
+		ctx.Ignore = true
+		// the expansions whose args are all parsed are completed
+		pushed := ctx.PushedArgs()
+		for len(x.expanding) > 0 && x.expanding[len(x.expanding)-1].base >= pushed {
+			x.expanding = x.expanding[:len(x.expanding)-1]
+		}
+		for _, e := range x.expanding {
+			if e.name == name {
+				ctx.Abort(&RecursionError{Chain: append(x.chain(), name)})
+				return
+			}
+		}
+		args, err := flag.SplitArgs(expansion)
+		if err != nil {
+			ctx.Abort(fmt.Errorf("alias %s: %v", name, err))
+			return
+		}
+		base := pushed
+		if base > 0 {
+			// excluding the alias itself
+			base--
+		}
+		x.expanding = append(x.expanding, aliasExpansion{name: name, base: base})
+		ctx.PushBack(args...)
+	}
+}
+
+// RegisterExt implements ExtRegistrar
+func (x *AliasExt) RegisterExt(parser *flag.Parser) {
+	parser.AddParseExt(flag.EvtStartCmd, x)
+	parser.AddParseExt(flag.EvtResolveCmd, x)
+	x.loaded = nil
+	x.expanding = nil
+}
+
+// HelpCommands implements help.CommandsSource
+func (x *AliasExt) HelpCommands(cmdPath []*flag.Command) (string, []*flag.Command) {
+	var prefix string
+	for i := 1; i < len(cmdPath); i++ {
+		prefix += cmdPath[i].Name + " "
+	}
+	var cmds []*flag.Command
+	for name, expansion := range x.loaded {
+		if !strings.HasPrefix(name, prefix) || strings.Contains(name[len(prefix):], " ") {
+			continue
+		}
+		cmds = append(cmds, &flag.Command{Name: name[len(prefix):], Desc: expansion})
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return HelpTitle, cmds
+}
+
+// chain lists the names of aliases being expanded
+func (x *AliasExt) chain() []string {
+	names := make([]string, len(x.expanding))
+	for i, e := range x.expanding {
+		names[i] = e.name
+	}
+	return names
+}
+
+func (x *AliasExt) loadAll() error {
+	x.loaded = make(map[string]string)
+	for name, expansion := range x.Aliases {
+		x.loaded[name] = expansion
+	}
+	for _, fn := range x.Files {
+		if err := x.loadFile(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadFile reads aliases from a file, the expansion can be a string or
+// a list of arguments which are quoted when joined
+func (x *AliasExt) loadFile(fn string) error {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var aliases map[string]interface{}
+	if err = yaml.Unmarshal(data, &aliases); err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	for name, val := range aliases {
+		switch v := val.(type) {
+		case string:
+			x.loaded[name] = v
+		case []interface{}:
+			args := make([]string, len(v))
+			for i, arg := range v {
+				args[i] = quote(fmt.Sprintf("%v", arg))
+			}
+			x.loaded[name] = strings.Join(args, " ")
+		default:
+			return fmt.Errorf("%s: invalid alias %s", fn, name)
+		}
+	}
+	return nil
+}
+
+// aliasName prefixes the name with the path of parent commands
+func aliasName(stack []*flag.ParsedCmd, name string) string {
+	for i := len(stack) - 1; i > 0; i-- {
+		name = stack[i].Cmd.Name + " " + name
+	}
+	return name
+}
+
+// quote quotes an argument for flag.SplitArgs if necessary
+func quote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\r\n'\"\\#$") {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
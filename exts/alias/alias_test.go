@@ -0,0 +1,190 @@
+package alias
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codingbrain/clix.go/exts/help"
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCmdDef = `---
+cli:
+    name: test
+    options:
+        - name: verbose
+          alias: [v]
+          type: boolean
+    commands:
+        - name: checkout
+          options:
+              - name: track
+                type: boolean
+              - name: message
+                alias: [m]
+          arguments:
+              - name: branch
+        - name: remote
+          commands:
+              - name: remove
+                arguments:
+                    - name: name
+`
+
+func loadCli(t *testing.T) *flag.CliDef {
+	cli, err := flag.DecodeCliDefString(testCmdDef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cli
+}
+
+func TestExpandAlias(t *testing.T) {
+	a := assert.New(t)
+	cli := loadCli(t)
+	x := NewExt().
+		Add("co", "checkout --track").
+		Add("cm", `co -m "a message"`).
+		Add("vco", "-v co").
+		Add("remote rm", "remove")
+
+	cli.Use(x)
+	r := cli.ParseArgs("test", "co", "dev")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal("checkout", cs.Cmd.Name)
+		a.True(cs.Vars["track"].(bool))
+		a.Equal("dev", cs.Vars["branch"])
+	}
+
+	r = cli.ParseArgs("test", "cm", "dev")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.True(cs.Vars["track"].(bool))
+		a.Equal("a message", cs.Vars["message"])
+		a.Equal("dev", cs.Vars["branch"])
+	}
+
+	r = cli.ParseArgs("test", "vco")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.True(r.CmdStack[0].Vars["verbose"].(bool))
+		a.Equal("checkout", r.CmdStack[1].Cmd.Name)
+	}
+
+	r = cli.ParseArgs("test", "remote", "rm", "origin")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 3) {
+		a.Equal("remove", r.CmdStack[2].Cmd.Name)
+		a.Equal("origin", r.CmdStack[2].Vars["name"])
+	}
+
+	r = cli.ParseArgs("test", "rm")
+	a.True(r.MissingCmd)
+	a.Equal([]string{"rm"}, r.UnparsedArgs)
+}
+
+func TestRecursiveAlias(t *testing.T) {
+	a := assert.New(t)
+	cli := loadCli(t)
+	x := NewExt().
+		Add("a", "-v b").
+		Add("b", "c").
+		Add("c", "a").
+		Add("bad", "checkout 'x")
+
+	cli.Use(x)
+	r := cli.ParseArgs("test", "a", "x")
+	if a.Error(r.Error) {
+		a.Equal("recursive alias: a -> b -> c -> a", r.Error.Error())
+		a.False(r.MissingCmd)
+		a.Equal([]string{"x"}, r.UnparsedArgs)
+	}
+
+	r = cli.ParseArgs("test", "bad")
+	if a.Error(r.Error) {
+		a.Contains(r.Error.Error(), "alias bad:")
+	}
+}
+
+func TestRepeatedAlias(t *testing.T) {
+	a := assert.New(t)
+	cli := loadCli(t)
+	x := NewExt().
+		Add("verb", "-v").
+		Add("both", "verb verb").
+		Add("co", "checkout")
+
+	cli.Use(x)
+	r := cli.ParseArgs("test", "verb", "verb", "co")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.True(r.CmdStack[0].Vars["verbose"].(bool))
+		a.Equal("checkout", r.CmdStack[1].Cmd.Name)
+	}
+
+	r = cli.ParseArgs("test", "both", "verb", "co")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.Equal("checkout", r.CmdStack[1].Cmd.Name)
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "clix-alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "aliases.yaml")
+	content := "co: checkout --track\nci: [checkout, -m, \"it's done\"]\n"
+	if err = ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := loadCli(t)
+	x := NewExt().Add("co", "checkout").Load(fn, filepath.Join(dir, "missing.yaml"))
+	cli.Use(x)
+	r := cli.ParseArgs("test", "co")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.True(r.CmdStack[1].Vars["track"].(bool))
+	}
+	r = cli.ParseArgs("test", "ci")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal("it's done", r.CmdStack[1].Vars["message"])
+	}
+
+	title, cmds := x.HelpCommands([]*flag.Command{cli.Cli})
+	a.Equal(HelpTitle, title)
+	if a.Len(cmds, 2) {
+		a.Equal("ci", cmds[0].Name)
+		a.Equal("co", cmds[1].Name)
+		a.Equal("checkout --track", cmds[1].Desc)
+	}
+
+	if err = ioutil.WriteFile(fn, []byte("co: {a: b}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r = cli.ParseArgs("test", "co")
+	if a.Error(r.Error) {
+		a.Contains(r.Error.Error(), "invalid alias co")
+	}
+}
+
+func TestHelpAliases(t *testing.T) {
+	a := assert.New(t)
+	cli := loadCli(t)
+	x := NewExt().Add("co", "checkout").Add("remote rm", "remove")
+	var _ help.CommandsSource = x
+
+	cli.Use(x)
+	r := cli.ParseArgs("test", "remote")
+	if a.Len(r.CmdStack, 2) {
+		title, cmds := x.HelpCommands([]*flag.Command{cli.Cli, r.CmdStack[1].Cmd})
+		a.Equal(HelpTitle, title)
+		if a.Len(cmds, 1) {
+			a.Equal("rm", cmds[0].Name)
+			a.Equal("remove", cmds[0].Desc)
+		}
+	}
+}
@@ -27,10 +27,21 @@ type binding struct {
 	execCmd execCmdFn
 }
 
-type modelUpdateFn func(opt *flag.Option, name string, value interface{})
+type modelUpdateFn func(opt *flag.Option, name string, value interface{}) error
 type execCmdFn func([]string) error
-type fieldUpdateFn func(value interface{})
-type valueUpdateFn func(v *reflect.Value, value interface{})
+type fieldUpdateFn func(value interface{}) error
+type valueUpdateFn func(v *reflect.Value, value interface{}) error
+
+// parseError is the error of a string which can't be parsed to the type
+// of the field, e.g. a value of dict without subtype
+type parseError struct {
+	value string
+	err   error
+}
+
+func (e *parseError) Error() string {
+	return e.err.Error()
+}
 
 func NewExt() *BindExt {
 	return &BindExt{b: make(map[string]*binding)}
@@ -54,11 +65,37 @@ func (x *BindExt) HandleParseEvent(event string, ctx *flag.ParseContext) {
 	prefix := keyFromStack(ctx.CmdStack())
 	for k, b := range x.b {
 		if prefix == "" || k == prefix || strings.HasPrefix(k, prefix+" ") {
-			b.update(ctx.Option, ctx.Name, ctx.Assigned)
+			if err := b.update(ctx.Option, ctx.Name, ctx.Assigned); err != nil {
+				reportError(ctx, err)
+			}
 		}
 	}
 }
 
+// reportError reports the value failed to update the model as an invalid
+// value of the option
+func reportError(ctx *flag.ParseContext, err error) {
+	pcmd := ctx.CmdAt(ctx.OptionAt)
+	if pcmd == nil || ctx.Option == nil {
+		return
+	}
+	varErr := &flag.VarError{
+		Name:    ctx.Option.Name,
+		Def:     ctx.Option,
+		ErrType: flag.VarErrBadVal,
+		Reason:  err.Error(),
+	}
+	if parseErr, ok := err.(*parseError); ok {
+		varErr.Value = &parseErr.value
+	}
+	for _, e := range pcmd.Errs {
+		if e.Name == varErr.Name && e.Def == varErr.Def && e.ErrType == varErr.ErrType {
+			return
+		}
+	}
+	pcmd.Errs = append(pcmd.Errs, varErr)
+}
+
 func (x *BindExt) ExecuteCmd(ctx *flag.ExecContext) {
 	b, exists := x.b[keyFromStack(ctx.Result.CmdStack)]
 	if exists && b.execCmd != nil && !ctx.HasErrors() {
@@ -129,18 +166,19 @@ func panicBadType(val interface{}) {
 	panicBadKind(reflect.TypeOf(val).Kind())
 }
 
-func sliceUpdater(v *reflect.Value, value interface{}) {
+func sliceUpdater(v *reflect.Value, value interface{}) error {
 	kind := v.Type().Elem().Kind()
 	if kind == reflect.Uint8 {
 		if str, ok := value.(string); ok {
 			v.SetBytes([]byte(str))
-			return
+			return nil
 		}
 	}
 	if t := reflect.TypeOf(value); t.Kind() == reflect.Array || t.Kind() == reflect.Slice {
 		if t.Elem().Kind() == kind {
 			reflect.Copy(*v, reflect.ValueOf(value))
-		} else if fn := valueUpdateFactory(v.Type().Elem()); fn != nil {
+		} else {
+			fn := valueUpdateFactory(v.Type().Elem())
 			vals := reflect.ValueOf(value)
 			if v.Cap() >= vals.Len() {
 				v.SetLen(vals.Len())
@@ -153,19 +191,34 @@ func sliceUpdater(v *reflect.Value, value interface{}) {
 					panicBadKind(src.Kind())
 				}
 				des := v.Index(i)
-				fn(&des, src.Interface())
+				if err := fn(&des, src.Interface()); err != nil {
+					return err
+				}
 			}
-			return
-		} else {
-			panicBadKind(t.Elem().Kind())
+			return nil
 		}
 	}
 	panicBadType(value)
+	return nil
 }
 
-func mapUpdater(v *reflect.Value, value interface{}) {
-	if sv := reflect.ValueOf(value); sv.Kind() == reflect.Map {
-		des := make(map[string]interface{})
+// mapUpdateFactory creates the updater converting the values of a map
+// to the element type, e.g. map[string]int, and nested maps recursively
+func mapUpdateFactory(t reflect.Type) valueUpdateFn {
+	var fn valueUpdateFn
+	return func(v *reflect.Value, value interface{}) error {
+		sv := reflect.ValueOf(value)
+		if sv.Kind() != reflect.Map {
+			panicBadType(value)
+		}
+		if t.Key().Kind() != reflect.String {
+			panicBadKind(t.Key().Kind())
+		}
+		if fn == nil {
+			// created lazily for recursive types
+			fn = valueUpdateFactory(t.Elem())
+		}
+		des := reflect.MakeMap(t)
 		for _, kv := range sv.MapKeys() {
 			vv := sv.MapIndex(kv)
 			if !kv.CanInterface() {
@@ -174,12 +227,49 @@ func mapUpdater(v *reflect.Value, value interface{}) {
 			if !vv.CanInterface() {
 				panicBadKind(vv.Kind())
 			}
-			key := fmt.Sprintf("%v", kv.Interface())
-			des[key] = vv.Interface()
+			key := reflect.ValueOf(fmt.Sprintf("%v", kv.Interface())).Convert(t.Key())
+			elem := reflect.New(t.Elem()).Elem()
+			if val := vv.Interface(); val != nil {
+				if err := fn(&elem, val); err != nil {
+					return err
+				}
+			}
+			des.SetMapIndex(key, elem)
 		}
-		v.Set(reflect.ValueOf(des))
-	} else {
-		panicBadType(value)
+		v.Set(des)
+		return nil
+	}
+}
+
+// structValueUpdateFactory creates the updater filling the fields of
+// a struct from a map by the keys of fields (see Bind)
+func structValueUpdateFactory(t reflect.Type) valueUpdateFn {
+	fns := make(map[int]valueUpdateFn)
+	return func(v *reflect.Value, value interface{}) error {
+		sv := reflect.ValueOf(value)
+		if sv.Kind() != reflect.Map {
+			panicBadType(value)
+		}
+		for i := 0; i < t.NumField(); i++ {
+			key := fieldMappingKey(t.Field(i))
+			if key == "" {
+				continue
+			}
+			vv := sv.MapIndex(reflect.ValueOf(key))
+			if !vv.IsValid() || !vv.CanInterface() || vv.Interface() == nil {
+				continue
+			}
+			fn, ok := fns[i]
+			if !ok {
+				fn = valueUpdateFactory(t.Field(i).Type)
+				fns[i] = fn
+			}
+			fv := v.Field(i)
+			if err := fn(&fv, vv.Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 }
 
@@ -213,15 +303,16 @@ func floatVal(val interface{}) (float64, bool) {
 func scalarUpdateFactory(t reflect.Type) valueUpdateFn {
 	switch t.Kind() {
 	case reflect.Bool, reflect.String:
-		return func(v *reflect.Value, value interface{}) {
+		return func(v *reflect.Value, value interface{}) error {
 			v.Set(reflect.ValueOf(value))
+			return nil
 		}
 	case reflect.Int,
 		reflect.Int8,
 		reflect.Int16,
 		reflect.Int32,
 		reflect.Int64:
-		return func(v *reflect.Value, value interface{}) {
+		return func(v *reflect.Value, value interface{}) error {
 			if int64Val, ok := intVal(value); ok {
 				v.SetInt(int64Val)
 			} else if uint64Val, ok := uintVal(value); ok {
@@ -229,6 +320,7 @@ func scalarUpdateFactory(t reflect.Type) valueUpdateFn {
 			} else {
 				panicBadType(value)
 			}
+			return nil
 		}
 	case reflect.Uint,
 		reflect.Uint8,
@@ -236,7 +328,7 @@ func scalarUpdateFactory(t reflect.Type) valueUpdateFn {
 		reflect.Uint32,
 		reflect.Uint64,
 		reflect.Uintptr:
-		return func(v *reflect.Value, value interface{}) {
+		return func(v *reflect.Value, value interface{}) error {
 			if uint64Val, ok := uintVal(value); ok {
 				v.SetUint(uint64Val)
 			} else if int64Val, ok := intVal(value); ok {
@@ -244,9 +336,10 @@ func scalarUpdateFactory(t reflect.Type) valueUpdateFn {
 			} else {
 				panicBadType(value)
 			}
+			return nil
 		}
 	case reflect.Float32, reflect.Float64:
-		return func(v *reflect.Value, value interface{}) {
+		return func(v *reflect.Value, value interface{}) error {
 			if float64Val, ok := floatVal(value); ok {
 				v.SetFloat(float64Val)
 			} else if int64Val, ok := intVal(value); ok {
@@ -256,9 +349,10 @@ func scalarUpdateFactory(t reflect.Type) valueUpdateFn {
 			} else {
 				panicBadType(value)
 			}
+			return nil
 		}
 	case reflect.Complex64, reflect.Complex128:
-		return func(v *reflect.Value, value interface{}) {
+		return func(v *reflect.Value, value interface{}) error {
 			if float64Val, ok := floatVal(value); ok {
 				v.SetComplex(complex(float64Val, 0))
 			} else if int64Val, ok := intVal(value); ok {
@@ -268,12 +362,53 @@ func scalarUpdateFactory(t reflect.Type) valueUpdateFn {
 			} else {
 				panicBadType(value)
 			}
+			return nil
 		}
 	}
 	return nil
 }
 
+// valueUpdateFactory creates the updater for the type, values of the
+// assignable types (e.g. time.Duration, net.IP, *url.URL) are set directly,
+// and strings (e.g. values of dict without subtype) are parsed to the type
 func valueUpdateFactory(t reflect.Type) valueUpdateFn {
+	fn := kindUpdateFactory(t)
+	parse := stringParser(t)
+	return func(v *reflect.Value, value interface{}) error {
+		if str, ok := value.(string); ok && parse != nil {
+			parsed, err := parse(str)
+			if err != nil {
+				return &parseError{value: str, err: err}
+			}
+			value = parsed
+		}
+		if fn == nil || value != nil && reflect.TypeOf(value).AssignableTo(t) {
+			v.Set(reflect.ValueOf(value))
+			return nil
+		}
+		return fn(v, value)
+	}
+}
+
+// stringParser creates the parser of strings to the type of option
+// inferred from t (see Build), or nil if it's not needed
+func stringParser(t reflect.Type) func(string) (interface{}, error) {
+	if t.Kind() == reflect.Ptr {
+		// parsed for the element
+		return nil
+	}
+	typ, list := optionType(t)
+	if typ == "" || typ == "string" || list || strings.HasPrefix(typ, "dict") {
+		return nil
+	}
+	opt := &flag.Option{Name: "value", Type: typ}
+	if err := (&flag.Command{Name: "value", Options: []*flag.Option{opt}}).Normalize(); err != nil {
+		return nil
+	}
+	return opt.ParseStrVal
+}
+
+func kindUpdateFactory(t reflect.Type) valueUpdateFn {
 	if fn := scalarUpdateFactory(t); fn != nil {
 		return fn
 	} else {
@@ -281,15 +416,19 @@ func valueUpdateFactory(t reflect.Type) valueUpdateFn {
 		case reflect.Array, reflect.Slice:
 			return sliceUpdater
 		case reflect.Map:
-			return mapUpdater
+			return mapUpdateFactory(t)
+		case reflect.Struct:
+			return structValueUpdateFactory(t)
 		case reflect.Ptr:
-			if fn := valueUpdateFactory(t.Elem()); fn != nil {
-				return func(v *reflect.Value, value interface{}) {
-					ptr := reflect.New(t.Elem())
-					val := reflect.Indirect(ptr)
-					fn(&val, value)
-					v.Set(ptr)
+			fn := valueUpdateFactory(t.Elem())
+			return func(v *reflect.Value, value interface{}) error {
+				ptr := reflect.New(t.Elem())
+				val := reflect.Indirect(ptr)
+				if err := fn(&val, value); err != nil {
+					return err
 				}
+				v.Set(ptr)
+				return nil
 			}
 		}
 	}
@@ -297,45 +436,69 @@ func valueUpdateFactory(t reflect.Type) valueUpdateFn {
 }
 
 func fieldUpdateFactory(v *reflect.Value) fieldUpdateFn {
-	if fn := valueUpdateFactory(v.Type()); fn != nil {
-		return func(value interface{}) {
-			fn(v, value)
-		}
-	} else {
-		return func(value interface{}) {
-			v.Set(reflect.ValueOf(value))
-		}
+	fn := valueUpdateFactory(v.Type())
+	return func(value interface{}) error {
+		return fn(v, value)
 	}
 }
 
-func structUpdateFn(model *reflect.Value) modelUpdateFn {
+// structMapper maps the fields of model, including the fields of embedded
+// structs (not subcommands, see Build) unless the keys are mapped
+func structMapper(model *reflect.Value, mapper map[string]fieldUpdateFn) {
 	t := model.Type()
-	mapper := make(map[string]fieldUpdateFn)
+	var embedded []reflect.Value
 	for i := 0; i < t.NumField(); i++ {
-		if key := fieldMappingKey(t.Field(i)); key != "" {
+		f := t.Field(i)
+		if key := fieldMappingKey(f); key != "" {
 			v := model.Field(i)
 			mapper[key] = fieldUpdateFactory(&v)
 		}
+		if _, isCmd := fieldTagOf(f).lookup(cmdKey); f.Anonymous && !isCmd && structType(f.Type) != nil {
+			v := model.Field(i)
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() && v.CanSet() {
+					v.Set(reflect.New(f.Type.Elem()))
+				}
+				v = v.Elem()
+			}
+			if v.IsValid() {
+				embedded = append(embedded, v)
+			}
+		}
 	}
-	return func(opt *flag.Option, name string, value interface{}) {
+	for _, v := range embedded {
+		fields := make(map[string]fieldUpdateFn)
+		structMapper(&v, fields)
+		for key, fn := range fields {
+			if _, exists := mapper[key]; !exists {
+				mapper[key] = fn
+			}
+		}
+	}
+}
+
+func structUpdateFn(model *reflect.Value) modelUpdateFn {
+	mapper := make(map[string]fieldUpdateFn)
+	structMapper(model, mapper)
+	return func(opt *flag.Option, name string, value interface{}) error {
 		if opt == nil {
-			return
+			return nil
 		}
 		if val, ok := opt.TagBool(optionTag); ok && !val {
 			// bind disabled
-			return
+			return nil
 		} else if bindKey, ok := opt.TagString(optionTag); ok && bindKey != "" {
 			if bindKey == "-" {
 				// bind disabled
-				return
+				return nil
 			}
 			name = bindKey
 		} else {
 			name = opt.Name
 		}
 		if fn, ok := mapper[name]; ok {
-			fn(value)
+			return fn(value)
 		}
-		return
+		return nil
 	}
 }
@@ -2,7 +2,10 @@ package bind
 
 import (
 	"errors"
+	"net"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/codingbrain/clix.go/flag"
 	"github.com/stretchr/testify/assert"
@@ -275,3 +278,180 @@ func TestBindExec(t *testing.T) {
 		}
 	}
 }
+
+type testBindTypes struct {
+	Timeout  time.Duration
+	Since    *time.Time
+	Limit    uint64
+	Addr     []net.IP
+	Net      *net.IPNet
+	Endpoint *url.URL
+}
+
+func TestStructBindTypes(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(`---
+cli:
+    name: test
+    options:
+        - name: timeout
+          type: duration
+          default: 1m
+        - name: since
+          type: time
+        - name: limit
+          type: size
+        - name: addr
+          type: ip
+          list: true
+        - name: net
+          type: cidr
+        - name: endpoint
+          type: url
+`)
+	if !a.NoError(err) {
+		return
+	}
+	s := &testBindTypes{}
+	err = cli.
+		Use(NewExt().Bind(s)).
+		ParseArgs("test", "--since=2020-01-02T03:04:05Z", "--limit=2KiB",
+			"--addr=10.0.0.1", "--addr=10.0.0.2", "--net=10.0.0.0/8",
+			"--endpoint=http://localhost:8080").
+		Exec()
+	if a.NoError(err) {
+		a.Equal(time.Minute, s.Timeout)
+		if a.NotNil(s.Since) {
+			a.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), *s.Since)
+		}
+		a.Equal(uint64(2048), s.Limit)
+		if a.Len(s.Addr, 2) {
+			a.Equal("10.0.0.1", s.Addr[0].String())
+			a.Equal("10.0.0.2", s.Addr[1].String())
+		}
+		if a.NotNil(s.Net) {
+			a.Equal("10.0.0.0/8", s.Net.String())
+		}
+		if a.NotNil(s.Endpoint) {
+			a.Equal("localhost:8080", s.Endpoint.Host)
+		}
+	}
+}
+
+type testBindCount struct {
+	Verbose int
+	Debug   uint8
+}
+
+func TestStructBindCount(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(`---
+cli:
+    name: test
+    options:
+        - name: verbose
+          alias: [v]
+          type: count
+        - name: debug
+          alias: [d]
+          type: count
+`)
+	if !a.NoError(err) {
+		return
+	}
+	s := &testBindCount{}
+	err = cli.
+		Use(NewExt().Bind(s)).
+		ParseArgs("test", "-vvd", "-v", "--debug=4").
+		Exec()
+	if a.NoError(err) {
+		a.Equal(3, s.Verbose)
+		a.Equal(uint8(4), s.Debug)
+	}
+}
+
+type testBindDB struct {
+	Host    string
+	Port    int
+	Timeout time.Duration
+}
+
+type testBindMaps struct {
+	Ports    map[string]int
+	Flags    map[string]bool
+	Nested   map[string]map[string]int64
+	Timeouts map[string]time.Duration
+	DB       testBindDB `n:"db"`
+	Servers  map[string]*testBindDB
+}
+
+func TestStructBindMaps(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(`---
+cli:
+    name: test
+    options:
+        - name: ports
+          type: dict/int
+        - name: flags
+          type: dict/bool
+        - name: nested
+          type: dict/int
+          nested-keys: true
+        - name: timeouts
+          type: dict/duration
+        - name: db
+          type: dict
+          default:
+              host: localhost
+              port: 5432
+        - name: servers
+          type: dict
+          nested-keys: true
+`)
+	if !a.NoError(err) {
+		return
+	}
+	s := &testBindMaps{}
+	err = cli.
+		Use(NewExt().Bind(s)).
+		ParseArgs("test", "--ports=http=80", "--ports=https=443", "--flags=debug",
+			"--nested=a.x=1", "--nested=b.y=2", "--timeouts=read=5s",
+			"--db=timeout=1s", "--servers=s1.host=h1", "--servers=s1.port=1").
+		Exec()
+	if a.NoError(err) {
+		a.Equal(map[string]int{"http": 80, "https": 443}, s.Ports)
+		a.Equal(map[string]bool{"debug": true}, s.Flags)
+		a.Equal(map[string]map[string]int64{"a": {"x": 1}, "b": {"y": 2}}, s.Nested)
+		a.Equal(map[string]time.Duration{"read": 5 * time.Second}, s.Timeouts)
+		a.Equal(testBindDB{Host: "localhost", Port: 5432, Timeout: time.Second}, s.DB)
+		if a.Contains(s.Servers, "s1") {
+			a.Equal(&testBindDB{Host: "h1", Port: 1}, s.Servers["s1"])
+		}
+	}
+}
+
+func TestStructBindParseError(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(`---
+cli:
+    name: test
+    options:
+        - name: db
+          type: dict
+`)
+	if !a.NoError(err) {
+		return
+	}
+	s := &testBindMaps{}
+	r := cli.Use(NewExt().Bind(s)).ParseArgs("test", "--db=port=x", "--db=timeout=1s")
+	if a.True(r.HasErrors()) && a.Len(r.CmdStack[0].Errs, 1) {
+		e := r.CmdStack[0].Errs[0]
+		a.Equal(flag.VarErrBadVal, e.ErrType)
+		a.Equal("db", e.Name)
+		if a.NotNil(e.Value) {
+			a.Equal("x", *e.Value)
+		}
+		a.NotEmpty(e.Reason)
+	}
+}
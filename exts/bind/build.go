@@ -0,0 +1,355 @@
+package bind
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codingbrain/clix.go/flag"
+)
+
+// The tag of struct fields used by Build, and the keys in it
+var (
+	cliTag      = "cli"
+	aliasKey    = "alias"
+	descKey     = "desc"
+	defaultKey  = "default"
+	requiredKey = "required"
+	typeKey     = "type"
+	envKey      = "env"
+	argKey      = "arg"
+	cmdKey      = "cmd"
+)
+
+// valueTypeNames maps the types of values to the value types of options
+var valueTypeNames = map[reflect.Type]string{
+	reflect.TypeOf(time.Duration(0)): "duration",
+	reflect.TypeOf(time.Time{}):      "time",
+	reflect.TypeOf(net.IP(nil)):      "ip",
+	reflect.TypeOf(&net.IPNet{}):     "cidr",
+	reflect.TypeOf(&url.URL{}):       "url",
+}
+
+// Build creates a command from the struct type of model, which can then
+// be bound using BindAll.
+//
+// Each exported field becomes an option named by the same key used for
+// binding (see Bind), with the type inferred from the field type, and
+// these keys in the cli tag separated by semicolons (e.g.
+// cli:"alias=v;type=count;desc=more output"):
+//
+//	alias=v,verb  comma separated aliases
+//	desc=...      description
+//	default=...   default value, comma separated for lists
+//	required      the option/argument is required
+//	type=count    overrides the inferred type
+//	env=NAME      environment variable
+//	arg=0         the field is the argument at the position
+//
+// A field tagged with cli:"-" is skipped.
+//
+// Maps with string keys become dict options typed by the values (e.g.
+// map[string]int is dict/int), so do other struct fields filled from
+// the nested keys. The dicts of structs and maps of structs/maps accept
+// dotted keys for nested values (see Option.NestedKeys). Fields of
+// unsupported types without the type key are skipped.
+//
+// A field of struct (or pointer to struct) with the key cmd=name defines
+// a subcommand (named by the key of the field if name is empty), with
+// alias and desc keys. Fields of embedded structs (exported or not)
+// without the cmd key are merged into the command, e.g. options shared by
+// multiple commands.
+func Build(name string, model interface{}) (*flag.Command, error) {
+	t := structType(reflect.TypeOf(model))
+	if t == nil {
+		return nil, fmt.Errorf("model type not supported: %v", reflect.TypeOf(model))
+	}
+	cmd := &flag.Command{Name: name}
+	if err := buildCommand(cmd, t); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// BindAll binds model to the command, and the sub-structs of subcommands
+// (see Build) to the subcommands recursively
+func (x *BindExt) BindAll(model interface{}, cmds ...string) *BindExt {
+	x.Bind(model, cmds...)
+	x.bindSubCommands(reflect.Indirect(reflect.ValueOf(model)), cmds)
+	return x
+}
+
+func (x *BindExt) bindSubCommands(v reflect.Value, cmds []string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if skipField(f) || structType(f.Type) == nil {
+			continue
+		}
+		// embedded structs are merged even if unexported
+		name, isCmd := fieldTagOf(f).lookup(cmdKey)
+		merged := f.Anonymous && !isCmd
+		if !merged && (!isCmd || f.PkgPath != "") {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				if !fv.CanSet() {
+					continue
+				}
+				fv.Set(reflect.New(f.Type.Elem()))
+			}
+		} else {
+			fv = fv.Addr()
+		}
+		if merged {
+			x.bindSubCommands(fv.Elem(), cmds)
+			continue
+		}
+		if name == "" {
+			name = fieldMappingKey(f)
+		}
+		subCmds := append(append([]string{}, cmds...), name)
+		x.BindAll(fv.Interface(), subCmds...)
+	}
+}
+
+// structType returns the struct type t (or t points to),
+// or nil if t is not a struct or is a value type
+func structType(t reflect.Type) reflect.Type {
+	if _, ok := valueTypeNames[t]; ok {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if _, ok := valueTypeNames[t]; ok || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+type builtArg struct {
+	opt *flag.Option
+	pos int
+}
+
+func buildCommand(cmd *flag.Command, t reflect.Type) error {
+	var args []builtArg
+	if err := buildFields(cmd, t, &args); err != nil {
+		return err
+	}
+	sort.SliceStable(args, func(i, j int) bool {
+		return args[i].pos < args[j].pos
+	})
+	for i, arg := range args {
+		if i > 0 && args[i-1].pos == arg.pos {
+			return fmt.Errorf("duplicated argument position %d: %s", arg.pos, arg.opt.Name)
+		}
+		cmd.Arguments = append(cmd.Arguments, arg.opt)
+	}
+	return nil
+}
+
+func buildFields(cmd *flag.Command, t reflect.Type, args *[]builtArg) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if skipField(f) {
+			continue
+		}
+		tag := fieldTagOf(f)
+		if err := tag.check(f); err != nil {
+			return err
+		}
+		name, isCmd := tag.lookup(cmdKey)
+		// embedded structs are merged even if unexported
+		if st := structType(f.Type); st != nil && f.Anonymous && !isCmd {
+			if err := buildFields(cmd, st, args); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if isCmd {
+			st := structType(f.Type)
+			if st == nil {
+				return fmt.Errorf("subcommand %s is not a struct", f.Name)
+			}
+			if name == "" {
+				name = fieldMappingKey(f)
+			}
+			sub := &flag.Command{Name: name, Desc: tag[descKey], Alias: tag.list(aliasKey)}
+			if err := buildCommand(sub, st); err != nil {
+				return err
+			}
+			cmd.Commands = append(cmd.Commands, sub)
+			continue
+		}
+		opt, err := buildOption(f, tag)
+		if err != nil {
+			return err
+		}
+		if opt == nil {
+			continue
+		}
+		if pos, ok := tag.lookup(argKey); ok {
+			n, err := strconv.Atoi(pos)
+			if err != nil {
+				return fmt.Errorf("invalid argument position of %s: %s", f.Name, pos)
+			}
+			*args = append(*args, builtArg{opt: opt, pos: n})
+		} else {
+			cmd.Options = append(cmd.Options, opt)
+		}
+	}
+	return nil
+}
+
+func buildOption(f reflect.StructField, tag fieldTag) (*flag.Option, error) {
+	name := fieldMappingKey(f)
+	typ, list := optionType(f.Type)
+	if t := tag[typeKey]; t != "" {
+		typ = t
+	} else if typ == "" {
+		return nil, nil
+	}
+	opt := &flag.Option{
+		Name:       name,
+		Alias:      tag.list(aliasKey),
+		Desc:       tag[descKey],
+		Type:       typ,
+		List:       list,
+		Env:        tag[envKey],
+		NestedKeys: typ == "dict" && nestedKeys(f.Type),
+	}
+	if def, ok := tag.lookup(defaultKey); ok {
+		if list {
+			var vals []interface{}
+			for _, val := range strings.Split(def, ",") {
+				vals = append(vals, val)
+			}
+			opt.Default = vals
+		} else {
+			opt.Default = def
+		}
+	}
+	if req, ok := tag.lookup(requiredKey); ok {
+		opt.Required = true
+		if req != "" {
+			required, err := strconv.ParseBool(req)
+			if err != nil {
+				return nil, fmt.Errorf("invalid required key of %s: %s", f.Name, req)
+			}
+			opt.Required = required
+		}
+	}
+	return opt, nil
+}
+
+// nestedKeys tells whether a dict fills nested structs or maps
+func nestedKeys(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Map {
+		t = t.Elem()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Map
+}
+
+// optionType infers the type of option from the type of field
+func optionType(t reflect.Type) (typ string, list bool) {
+	if name, ok := valueTypeNames[t]; ok {
+		return name, false
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool", false
+	case reflect.String:
+		return "string", false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int", false
+	case reflect.Float32, reflect.Float64:
+		return "number", false
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			if typ, list := optionType(t.Elem()); typ != "" && typ != "dict" && !list {
+				return "dict/" + typ, false
+			}
+			return "dict", false
+		}
+	case reflect.Struct:
+		// filled from the nested keys
+		return "dict", false
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string", false
+		}
+		if typ, list := optionType(t.Elem()); !list && !strings.HasPrefix(typ, "dict") {
+			return typ, typ != ""
+		}
+	case reflect.Ptr:
+		return optionType(t.Elem())
+	}
+	return "", false
+}
+
+// fieldTag is the values by keys in the cli tag of a field
+type fieldTag map[string]string
+
+// skipField tells whether the field is tagged with cli:"-"
+func skipField(f reflect.StructField) bool {
+	return f.Tag.Get(cliTag) == "-"
+}
+
+// fieldTagOf parses the cli tag of f, a key without value (e.g. required)
+// maps to empty string
+func fieldTagOf(f reflect.StructField) fieldTag {
+	tag := make(fieldTag)
+	for _, item := range strings.Split(f.Tag.Get(cliTag), ";") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		key, val := item, ""
+		if pos := strings.Index(item, "="); pos >= 0 {
+			key, val = strings.TrimSpace(item[:pos]), item[pos+1:]
+		}
+		tag[key] = val
+	}
+	return tag
+}
+
+func (t fieldTag) check(f reflect.StructField) error {
+	for key := range t {
+		switch key {
+		case aliasKey, descKey, defaultKey, requiredKey, typeKey, envKey, argKey, cmdKey:
+		default:
+			return fmt.Errorf("unknown key in cli tag of %s: %s", f.Name, key)
+		}
+	}
+	return nil
+}
+
+func (t fieldTag) lookup(key string) (string, bool) {
+	val, ok := t[key]
+	return val, ok
+}
+
+func (t fieldTag) list(key string) []string {
+	if val := t[key]; val != "" {
+		return strings.Split(val, ",")
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package bind
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+type buildCommon struct {
+	Verbose int `cli:"alias=v;type=count;desc=more output"`
+}
+
+type buildUp struct {
+	Delay    time.Duration `cli:"alias=d;default=1s"`
+	Services []string      `cli:"arg=0" n:"service"`
+
+	executed []string
+}
+
+func (c *buildUp) Execute(args []string) error {
+	c.executed = c.Services
+	return nil
+}
+
+type buildDown struct {
+	Force bool
+	Name  string `cli:"arg=1;required"`
+	Zone  string `cli:"arg=0"`
+}
+
+type buildProxy struct {
+	Host string
+	Port int
+}
+
+type buildApp struct {
+	buildCommon
+	Name    string                 `cli:"alias=n;env=APP_NAME;default=app"`
+	Labels  map[string]interface{} `cli:"desc=labels"`
+	Ports   []int                  `n:"port" cli:"default=80,443"`
+	Limits  map[string]int         `cli:"alias=L"`
+	Proxy   buildProxy
+	Up      buildUp    `cli:"cmd;desc=start services"`
+	Down    *buildDown `cli:"cmd=stop;alias=down"`
+	Skipped string     `cli:"-"`
+	Ignored chan int
+}
+
+func TestBuild(t *testing.T) {
+	a := assert.New(t)
+	cmd, err := Build("app", &buildApp{})
+	a.NoError(err)
+	a.Equal("app", cmd.Name)
+	if a.Len(cmd.Options, 6) {
+		a.Equal(&flag.Option{Name: "verbose", Alias: []string{"v"}, Desc: "more output", Type: "count"}, cmd.Options[0])
+		a.Equal(&flag.Option{Name: "name", Alias: []string{"n"}, Type: "string", Env: "APP_NAME", Default: "app"}, cmd.Options[1])
+		a.Equal(&flag.Option{Name: "labels", Desc: "labels", Type: "dict"}, cmd.Options[2])
+		a.Equal(&flag.Option{Name: "port", Type: "int", List: true, Default: []interface{}{"80", "443"}}, cmd.Options[3])
+		a.Equal(&flag.Option{Name: "limits", Alias: []string{"L"}, Type: "dict/int"}, cmd.Options[4])
+		a.Equal(&flag.Option{Name: "proxy", Type: "dict", NestedKeys: true}, cmd.Options[5])
+	}
+	if a.Len(cmd.Commands, 2) {
+		up := cmd.Commands[0]
+		a.Equal("up", up.Name)
+		a.Equal("start services", up.Desc)
+		a.Equal(&flag.Option{Name: "delay", Alias: []string{"d"}, Type: "duration", Default: "1s"}, up.Options[0])
+		a.Equal(&flag.Option{Name: "service", Type: "string", List: true}, up.Arguments[0])
+		stop := cmd.Commands[1]
+		a.Equal("stop", stop.Name)
+		a.Equal([]string{"down"}, stop.Alias)
+		if a.Len(stop.Arguments, 2) {
+			a.Equal("zone", stop.Arguments[0].Name)
+			a.Equal("name", stop.Arguments[1].Name)
+			a.True(stop.Arguments[1].Required)
+		}
+	}
+	cli := &flag.CliDef{Cli: cmd}
+	a.NoError(cli.Normalize())
+
+	_, err = Build("bad", &struct {
+		Sub string `cli:"cmd"`
+	}{})
+	a.Error(err)
+	_, err = Build("bad", &struct {
+		A string `cli:"arg=0"`
+		B string `cli:"arg=0"`
+	}{})
+	a.Error(err)
+	_, err = Build("bad", &struct {
+		A string `cli:"alias=a;bad"`
+	}{})
+	a.Error(err)
+	_, err = Build("bad", "str")
+	a.Error(err)
+}
+
+func TestBuildAndBindAll(t *testing.T) {
+	a := assert.New(t)
+	cmd, err := Build("app", &buildApp{})
+	a.NoError(err)
+	cli := &flag.CliDef{Cli: cmd}
+	a.NoError(cli.Normalize())
+
+	app := &buildApp{}
+	cli.Use(NewExt().BindAll(app))
+	err = cli.ParseArgs("app", "-vv", "--port=8080", "-L", "cpu=2", "--proxy=host=p", "--proxy=port=3128",
+		"up", "-d", "5s", "web", "db").Exec()
+	a.NoError(err)
+	a.Equal(2, app.Verbose)
+	a.Equal("app", app.Name)
+	a.Equal([]int{80, 443, 8080}, app.Ports)
+	a.Equal(map[string]int{"cpu": 2}, app.Limits)
+	a.Equal(buildProxy{Host: "p", Port: 3128}, app.Proxy)
+	a.Equal(5*time.Second, app.Up.Delay)
+	a.Equal([]string{"web", "db"}, app.Up.executed)
+	a.NotNil(app.Down)
+
+	err = cli.ParseArgs("app", "down", "--force", "z1", "svc").Exec()
+	a.NoError(err)
+	a.True(app.Down.Force)
+	a.Equal("z1", app.Down.Zone)
+	a.Equal("svc", app.Down.Name)
+}
@@ -0,0 +1,271 @@
+package complete
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/codingbrain/clix.go/gen"
+	"github.com/codingbrain/clix.go/gen/completion"
+)
+
+const (
+	// TagCompletionCmd marks the injected command for completion scripts
+	TagCompletionCmd = "completion-cmd"
+	// TagComplete on an option or argument specifies the key of ValuesFunc
+	TagComplete = "complete"
+)
+
+var (
+	// DefaultCmdName is the default name of the injected command
+	DefaultCmdName = "completion"
+
+	// ErrCompleteRequested aborts parsing when completion.CompleteCmdName
+	// is the first argument
+	ErrCompleteRequested = errors.New("completion requested")
+)
+
+// ValuesFunc provides candidates for the value of an option or argument,
+// a candidate may be followed by a TAB and description.
+// The candidates are filtered by pt.Prefix afterwards.
+type ValuesFunc func(pt *flag.CompletePoint) []string
+
+// CompleteExt defines the completion extension which injects a subcommand
+// to root command for printing completion scripts, and must be hooked up to
+// - EvtStartCmd
+// - EvtParseArg
+// - Execution
+//
+// The subcommand is only injected when root command has subcommands.
+// The generated scripts call the program with hidden completion.CompleteCmdName
+// as the first argument, and candidates of option and argument values are
+// provided by ValuesFunc registered with the key which is the value of
+// TagComplete on the option, or the option name, otherwise Choices are used.
+// The extension must be used before help extension as the parsing is aborted
+// with ErrCompleteRequested.
+type CompleteExt struct {
+	// CmdName is the name of injected subcommand
+	CmdName string
+	// Program overrides the program name in scripts
+	Program string
+	// Output is where the scripts are written, default is os.Stdout
+	Output io.Writer
+	// Values are candidate providers keyed by tag value or option name
+	Values map[string]ValuesFunc
+}
+
+// NewExt creates completion extension
+func NewExt() *CompleteExt {
+	return &CompleteExt{
+		CmdName: DefaultCmdName,
+		Values:  make(map[string]ValuesFunc),
+	}
+}
+
+// Name overrides the name of injected subcommand
+func (x *CompleteExt) Name(name string) *CompleteExt {
+	x.CmdName = name
+	return x
+}
+
+// ProgramName overrides the program name in scripts
+func (x *CompleteExt) ProgramName(name string) *CompleteExt {
+	x.Program = name
+	return x
+}
+
+// UseOutput specifies where the scripts are written
+func (x *CompleteExt) UseOutput(w io.Writer) *CompleteExt {
+	x.Output = w
+	return x
+}
+
+// Complete registers the provider of candidates for values
+func (x *CompleteExt) Complete(key string, fn ValuesFunc) *CompleteExt {
+	x.Values[key] = fn
+	return x
+}
+
+// HandleParseEvent implements parse extension
+func (x *CompleteExt) HandleParseEvent(event string, ctx *flag.ParseContext) {
+	if len(ctx.CmdStack()) != 1 {
+		return
+	}
+	switch event {
+	case flag.EvtStartCmd:
+		x.injectCmd(ctx)
+	case flag.EvtParseArg:
+		if ctx.Name == completion.CompleteCmdName {
+			ctx.Ignore = true
+			ctx.Abort(ErrCompleteRequested)
+		}
+	}
+}
+
+// injectCmd adds the subcommand to root command once, it's not injected if
+// any subcommand has the same name or is injected by another extension
+func (x *CompleteExt) injectCmd(ctx *flag.ParseContext) {
+	root := ctx.CurrentCmd().Cmd
+	if len(root.Commands) == 0 || root.FindCommand(x.CmdName) != nil {
+		return
+	}
+	for _, sub := range root.Commands {
+		if isCmd, _ := sub.TagBool(TagCompletionCmd); isCmd {
+			return
+		}
+	}
+	cmd := &flag.Command{
+		Name: x.CmdName,
+		Desc: "Print shell completion script",
+		Arguments: []*flag.Option{
+			&flag.Option{
+				Name:     "shell",
+				Desc:     "One of " + strings.Join(completion.Shells, ", "),
+				Required: true,
+			},
+		},
+		Tags: map[string]interface{}{TagCompletionCmd: true},
+	}
+	if err := cmd.Normalize(); err != nil {
+		ctx.Abort(err)
+		return
+	}
+	root.Commands = append(root.Commands, cmd)
+	root.CmdMap[cmd.Name] = cmd
+}
+
+// ExecuteCmd implements execution extension
+func (x *CompleteExt) ExecuteCmd(ctx *flag.ExecContext) {
+	pcmd := ctx.Cmd()
+	if pcmd == nil {
+		return
+	}
+	out := x.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	if ctx.Result.Error == ErrCompleteRequested {
+		ctx.Result.Error = nil
+		x.printCandidates(out, ctx.Result.Program, ctx.CmdAt(0).Cmd, ctx.Result.UnparsedArgs)
+		ctx.Done(nil)
+		return
+	}
+	if ctx.HasErrors() {
+		return
+	}
+	if isCmd, _ := pcmd.Cmd.TagBool(TagCompletionCmd); !isCmd {
+		return
+	}
+	w := &gen.Writer{Output: out, IndentSize: gen.DefaultIndentSize}
+	b := &completion.ScriptBackend{Program: x.Program, Dynamic: true}
+	b.Shell, _ = pcmd.Vars["shell"].(string)
+	ctx.Done(b.Generate(w, ctx.CmdAt(0).Cmd))
+}
+
+// printCandidates writes the output of completion.CompleteCmdName
+func (x *CompleteExt) printCandidates(out io.Writer, program string, root *flag.Command, words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	words, shellLen := mergeAssigns(words)
+	word := words[len(words)-1]
+	pt := root.Parser().Complete(append([]string{program}, words...)...)
+	cands, directive := x.candidates(pt)
+	// the candidates are full words, but the shell may only see part of it
+	strip := len(word) - shellLen
+	for _, cand := range cands {
+		if pt.Kind == flag.CompleteOptVal {
+			cand = word[:len(word)-len(pt.Prefix)] + cand
+		}
+		if strings.HasPrefix(cand, word) {
+			fmt.Fprintln(out, cand[strip:])
+		}
+	}
+	fmt.Fprintln(out, ":"+directive)
+}
+
+func (x *CompleteExt) candidates(pt *flag.CompletePoint) ([]string, string) {
+	var cands []string
+	switch pt.Kind {
+	case flag.CompleteCmd:
+		for _, sub := range completion.VisibleCommands(pt.CurrentCmd().Cmd) {
+			for _, name := range append([]string{sub.Name}, sub.Alias...) {
+				cands = append(cands, candidate(name, sub.Desc))
+			}
+		}
+	case flag.CompleteOptName:
+		for at, pcmd := range pt.CmdStack {
+			for _, opt := range pcmd.Cmd.Options {
+				if !completion.VisibleOption(opt) || opt.Local && at < len(pt.CmdStack)-1 {
+					continue
+				}
+				for _, word := range completion.OptionWords(opt) {
+					cands = append(cands, candidate(word, opt.Desc))
+				}
+			}
+		}
+	case flag.CompleteOptVal, flag.CompleteArg:
+		if fn := x.valuesFunc(pt.Option); fn != nil {
+			cands = fn(pt)
+		} else if pt.Option != nil && len(pt.Option.Choices) > 0 {
+			cands = pt.Option.ChoiceStrings()
+		} else if pt.Option == nil || pt.Option.ExpectValue() {
+			return nil, completion.DirectiveFiles
+		}
+	}
+	return cands, completion.DirectiveNone
+}
+
+func (x *CompleteExt) valuesFunc(opt *flag.Option) ValuesFunc {
+	if opt == nil {
+		return nil
+	}
+	if key, ok := opt.TagString(TagComplete); ok {
+		if fn := x.Values[key]; fn != nil {
+			return fn
+		}
+	}
+	return x.Values[opt.Name]
+}
+
+// mergeAssigns joins "--name", "=", "value" which are split by bash, and
+// returns the length of the last word seen by the shell
+func mergeAssigns(words []string) ([]string, int) {
+	var merged []string
+	shellLen := 0
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		shellLen = len(word)
+		if strings.HasPrefix(word, "--") && !strings.Contains(word, "=") &&
+			i+1 < len(words) && words[i+1] == "=" {
+			word += "="
+			i++
+			shellLen = 1
+			if i+1 < len(words) {
+				i++
+				word += words[i]
+				shellLen = len(words[i])
+			}
+		}
+		merged = append(merged, word)
+	}
+	return merged, shellLen
+}
+
+// candidate formats a line of candidate with the first line of description
+func candidate(value, desc string) string {
+	if desc = completion.Summary(desc); desc != "" {
+		value += "\t" + desc
+	}
+	return value
+}
+
+// RegisterExt implements ExtRegistrar
+func (x *CompleteExt) RegisterExt(parser *flag.Parser) {
+	parser.AddParseExt(flag.EvtStartCmd, x)
+	parser.AddParseExt(flag.EvtParseArg, x)
+	parser.AddExecExt(x)
+}
@@ -0,0 +1,153 @@
+package complete
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCmdDef = `---
+cli:
+    name: test
+    options:
+        - name: verbose
+          alias: [v]
+          type: bool
+          description: verbose output
+        - name: server
+          description: server's address
+        - name: format
+          choices: [json, yaml]
+    commands:
+        - name: up
+          alias: [u]
+          description: start it
+          options:
+              - name: wait
+                type: bool
+          arguments:
+              - name: object
+                tags:
+                    complete: obj
+        - name: down
+          commands:
+              - name: now
+        - name: stop
+          replaced-by: down
+`
+
+func runCompletion(t *testing.T, ext *CompleteExt, args ...string) string {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(testCmdDef)
+	if !a.NoError(err) {
+		return ""
+	}
+	var out bytes.Buffer
+	err = cli.Use(ext.UseOutput(&out)).
+		ParseArgs(append([]string{"test"}, args...)...).
+		Exec()
+	a.NoError(err)
+	return out.String()
+}
+
+func TestBashScript(t *testing.T) {
+	a := assert.New(t)
+	script := runCompletion(t, NewExt(), "completion", "bash")
+	a.Contains(script, "complete -F _test_completion test")
+	a.Contains(script, `test __complete "${COMP_WORDS[@]:1:COMP_CWORD}"`)
+	a.Contains(script, `*) COMPREPLY+=("${line%%$'\t'*}") ;;`)
+}
+
+func TestZshScript(t *testing.T) {
+	a := assert.New(t)
+	script := runCompletion(t, NewExt(), "completion", "zsh")
+	a.Contains(script, "#compdef test")
+	a.Contains(script, "compdef _test test")
+	a.Contains(script, `test __complete "${(@)words[2,CURRENT]}"`)
+}
+
+func TestFishScript(t *testing.T) {
+	a := assert.New(t)
+	script := runCompletion(t, NewExt(), "completion", "fish")
+	a.Contains(script, "function __test_complete")
+	a.Contains(script, "complete -c test -f -a '(__test_complete)'")
+}
+
+func TestCompleteCmds(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("up\tstart it\nu\tstart it\n:none\n",
+		runCompletion(t, NewExt(), "__complete", "u"))
+	a.Equal("now\n:none\n",
+		runCompletion(t, NewExt(), "__complete", "down", ""))
+	// deprecated commands are not completed
+	a.Equal("up\tstart it\nu\tstart it\ndown\ncompletion\tPrint shell completion script\n:none\n",
+		runCompletion(t, NewExt(), "__complete", ""))
+}
+
+func TestInjectCmdOnce(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(testCmdDef)
+	if !a.NoError(err) {
+		return
+	}
+	var out bytes.Buffer
+	ext := NewExt().UseOutput(&out)
+	cli.Use(ext)
+	a.NoError(cli.ParseArgs("test", "up").Exec())
+	a.NoError(cli.ParseArgs("test", "up").Exec())
+	ext.Name("completions")
+	a.NoError(cli.ParseArgs("test", "up").Exec())
+	a.NoError(cli.Cli.Normalize())
+	a.NoError(cli.ParseArgs("test", "up").Exec())
+	count := 0
+	for _, sub := range cli.Cli.Commands {
+		if isCmd, _ := sub.TagBool(TagCompletionCmd); isCmd {
+			count++
+		}
+	}
+	a.Equal(1, count)
+}
+
+func TestCompleteOptNames(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("--verbose\tverbose output\n--no-verbose\tverbose output\n"+
+		"--server\tserver's address\n--format\n--wait\n--no-wait\n:none\n",
+		runCompletion(t, NewExt(), "__complete", "up", "--"))
+	a.Equal("--no-verbose\tverbose output\n--no-wait\n:none\n",
+		runCompletion(t, NewExt(), "__complete", "up", "--no"))
+}
+
+func TestCompleteValues(t *testing.T) {
+	a := assert.New(t)
+	ext := NewExt().Complete("server", func(pt *flag.CompletePoint) []string {
+		return []string{"local\tlocal server", "remote"}
+	}).Complete("obj", func(pt *flag.CompletePoint) []string {
+		return []string{"pod", "node"}
+	})
+	a.Equal("--server=remote\n:none\n",
+		runCompletion(t, ext, "__complete", "up", "--server=r"))
+	a.Equal("--server=local\tlocal server\n--server=remote\n:none\n",
+		runCompletion(t, ext, "__complete", "--server="))
+	// bash splits by "="
+	a.Equal("local\tlocal server\n:none\n",
+		runCompletion(t, ext, "__complete", "--server", "=", "l"))
+	a.Equal("=local\tlocal server\n=remote\n:none\n",
+		runCompletion(t, ext, "__complete", "--server", "="))
+	a.Equal("node\n:none\n",
+		runCompletion(t, ext, "__complete", "up", "n"))
+	a.Equal(":files\n",
+		runCompletion(t, NewExt(), "__complete", "up", ""))
+	a.Equal("--format=json\n--format=yaml\n:none\n",
+		runCompletion(t, NewExt(), "__complete", "--format="))
+}
+
+func TestUnsupportedShell(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(testCmdDef)
+	if a.NoError(err) {
+		err = cli.Use(NewExt()).ParseArgs("test", "completion", "cmd").Exec()
+		a.Error(err)
+	}
+}
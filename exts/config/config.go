@@ -0,0 +1,315 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/codingbrain/clix.go/flag"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// DefaultBaseName is the base name of configuration files to search
+	DefaultBaseName = "config"
+	// DefaultFileExts are the extensions of configuration files to search
+	DefaultFileExts = []string{".yaml", ".yml", ".json"}
+)
+
+// ConfigExt defines the config extension which loads values of options
+// from configuration files and must be hooked up to
+// - EvtStartCmd
+// - EvtAssigned
+//
+// The configuration file is a mapping from option names to values, and the
+// options of a subcommand are nested in a mapping under the subcommand name.
+// The values are applied with precedence: file < environment < command line.
+// Configuration files are loaded in order (later ones win):
+// - $XDG_CONFIG_DIRS/PROGRAM/config.EXT
+// - $XDG_CONFIG_HOME/PROGRAM/config.EXT
+// - .PROGRAM.EXT in working directory
+// - the files explicitly specified
+// - the file specified by the option named FileOpt
+type ConfigExt struct {
+	// Files are always loaded, and it's an error if any is missing
+	Files []string
+	// FileOpt is the name of option which specifies a configuration file
+	FileOpt string
+	// Program overrides the name for searching configuration files,
+	// default is the name of root command
+	Program string
+	// XDG enables searching XDG config directories
+	XDG bool
+	// Cwd enables searching working directory
+	Cwd bool
+
+	loaded []*configFile
+}
+
+type configFile struct {
+	name    string
+	entries yaml.MapSlice
+	lines   []string
+}
+
+// NewExt creates config extension
+func NewExt() *ConfigExt {
+	return &ConfigExt{XDG: true, Cwd: true}
+}
+
+// Load specifies configuration files to load
+func (x *ConfigExt) Load(files ...string) *ConfigExt {
+	x.Files = append(x.Files, files...)
+	return x
+}
+
+// FileOption specifies the option which accepts a configuration file
+func (x *ConfigExt) FileOption(name string) *ConfigExt {
+	x.FileOpt = name
+	return x
+}
+
+// ProgramName overrides the name for searching configuration files
+func (x *ConfigExt) ProgramName(name string) *ConfigExt {
+	x.Program = name
+	return x
+}
+
+// NoSearch disables searching configuration files
+func (x *ConfigExt) NoSearch() *ConfigExt {
+	x.XDG = false
+	x.Cwd = false
+	return x
+}
+
+// HandleParseEvent implements parse extension
+func (x *ConfigExt) HandleParseEvent(event string, ctx *flag.ParseContext) {
+	switch event {
+	case flag.EvtStartCmd:
+		at := len(ctx.CmdStack()) - 1
+		if at == 0 {
+			x.loaded = nil
+			if err := x.loadAll(ctx.CurrentCmd().Cmd.Name); err != nil {
+				ctx.Abort(err)
+				return
+			}
+		}
+		for _, f := range x.loaded {
+			f.apply(ctx, at, false)
+		}
+	case flag.EvtAssigned:
+		if x.FileOpt == "" || ctx.Option == nil || ctx.Option.Name != x.FileOpt {
+			return
+		}
+		fn, ok := ctx.Assigned.(string)
+		if !ok || fn == "" {
+			return
+		}
+		pcmd := ctx.CmdAt(ctx.OptionAt)
+		_, fromArgs := pcmd.Opts[ctx.Name]
+		_, fromEnv := pcmd.Envs[ctx.Name]
+		f, err := loadFile(fn, fromArgs || fromEnv)
+		if err != nil {
+			ctx.Abort(err)
+			return
+		} else if f == nil {
+			return
+		}
+		x.loaded = append(x.loaded, f)
+		for at := range ctx.CmdStack() {
+			f.apply(ctx, at, true)
+		}
+	}
+}
+
+// RegisterExt implements ExtRegistrar
+func (x *ConfigExt) RegisterExt(parser *flag.Parser) {
+	parser.AddParseExt(flag.EvtStartCmd, x)
+	parser.AddParseExt(flag.EvtAssigned, x)
+	x.loaded = nil
+}
+
+func (x *ConfigExt) loadAll(program string) error {
+	if x.Program != "" {
+		program = x.Program
+	}
+	var candidates []string
+	if x.XDG {
+		dirs := filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS"))
+		if len(dirs) == 0 {
+			dirs = []string{"/etc/xdg"}
+		}
+		// the first directory is the most important one
+		for i := len(dirs) - 1; i >= 0; i-- {
+			candidates = append(candidates, filepath.Join(dirs[i], program, DefaultBaseName))
+		}
+		home := os.Getenv("XDG_CONFIG_HOME")
+		if home == "" && os.Getenv("HOME") != "" {
+			home = filepath.Join(os.Getenv("HOME"), ".config")
+		}
+		if home != "" {
+			candidates = append(candidates, filepath.Join(home, program, DefaultBaseName))
+		}
+	}
+	if x.Cwd {
+		candidates = append(candidates, "."+program)
+	}
+	for _, base := range candidates {
+		for _, ext := range DefaultFileExts {
+			if f, err := loadFile(base+ext, false); err != nil {
+				return err
+			} else if f != nil {
+				x.loaded = append(x.loaded, f)
+				break
+			}
+		}
+	}
+	for _, fn := range x.Files {
+		if f, err := loadFile(fn, true); err != nil {
+			return err
+		} else {
+			x.loaded = append(x.loaded, f)
+		}
+	}
+	return nil
+}
+
+// loadFile reads a configuration file, a missing file is ignored
+// with a nil result if it's not required
+func loadFile(fn string, required bool) (*configFile, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return nil, nil
+		}
+		return nil, err
+	}
+	f := &configFile{name: fn, lines: strings.Split(string(data), "\n")}
+	if err = yaml.Unmarshal(data, &f.entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", fn, err)
+	}
+	return f, nil
+}
+
+// apply assigns the values to the command at specified position in stack.
+// The values already assigned from command line or environment are skipped.
+func (f *configFile) apply(ctx *flag.ParseContext, at int, notify bool) {
+	entries := f.entries
+	var path []string
+	for i := 1; i <= at && entries != nil; i++ {
+		var key string
+		entries, key = findCmdEntries(entries, ctx.CmdAt(i-1).Cmd, ctx.CmdAt(i).Cmd)
+		path = append(path, key)
+	}
+	if entries == nil {
+		return
+	}
+	pcmd := ctx.CmdAt(at)
+	for _, item := range entries {
+		key := fmt.Sprintf("%v", item.Key)
+		if _, ok := item.Value.(yaml.MapSlice); ok && pcmd.Cmd.FindCommand(key) != nil {
+			continue
+		}
+		opt := pcmd.Cmd.FindOption(key)
+		if opt == nil {
+			pcmd.Errs = append(pcmd.Errs, &flag.VarError{
+				Name:    key,
+				ErrType: flag.VarErrNoDef,
+				File:    f.name,
+				Line:    f.lineOf(append(path, key)),
+			})
+			continue
+		}
+		if _, exists := pcmd.Opts[opt.Name]; exists {
+			continue
+		}
+		if _, exists := pcmd.Envs[opt.Name]; exists {
+			continue
+		}
+		val, err := opt.ParseVal(plainVal(item.Value))
+		if err != nil {
+			str := fmt.Sprintf("%v", item.Value)
+			varErr := &flag.VarError{
+				Name:    opt.Name,
+				Def:     opt,
+				Value:   &str,
+				ErrType: flag.VarErrBadVal,
+				File:    f.name,
+				Line:    f.lineOf(append(path, key)),
+			}
+			if valErr, ok := err.(*flag.ValueError); ok {
+				varErr.Reason = valErr.Reason
+			}
+			pcmd.Errs = append(pcmd.Errs, varErr)
+		} else if notify {
+			ctx.AssignVarAt(at, opt, val).SetSourceAt(at, opt.Name, flag.VarSrcConfig)
+		} else {
+			ctx.SetVarAt(at, opt.Name, val).SetSourceAt(at, opt.Name, flag.VarSrcConfig)
+		}
+	}
+}
+
+// lineOf locates the line of a key by scanning the indented lines
+func (f *configFile) lineOf(path []string) int {
+	lineNo := 0
+	start, indent := 0, -1
+	for _, key := range path {
+		re := regexp.MustCompile(`^["']?` + regexp.QuoteMeta(key) + `["']?\s*:`)
+		found, block := false, -1
+		for i := start; i < len(f.lines) && !found; i++ {
+			text := strings.TrimLeft(f.lines[i], " \t")
+			if text == "" || text[0] == '#' || text == "{" || text == "[" {
+				continue
+			}
+			ind := len(f.lines[i]) - len(text)
+			if ind <= indent {
+				break
+			}
+			if block < 0 {
+				block = ind
+			}
+			if ind == block && re.MatchString(text) {
+				found, lineNo, start, indent = true, i+1, i+1, ind
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return lineNo
+}
+
+func findCmdEntries(entries yaml.MapSlice, parent, cmd *flag.Command) (yaml.MapSlice, string) {
+	for _, item := range entries {
+		key := fmt.Sprintf("%v", item.Key)
+		if parent.FindCommand(key) != cmd {
+			continue
+		}
+		if sub, ok := item.Value.(yaml.MapSlice); ok {
+			return sub, key
+		}
+	}
+	return nil, ""
+}
+
+// plainVal converts yaml.MapSlice to map which is expected by flag.Option
+func plainVal(val interface{}) interface{} {
+	switch v := val.(type) {
+	case yaml.MapSlice:
+		dict := make(map[string]interface{})
+		for _, item := range v {
+			dict[fmt.Sprintf("%v", item.Key)] = plainVal(item.Value)
+		}
+		return dict
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = plainVal(item)
+		}
+		return list
+	}
+	return val
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCmdDef = `---
+cli:
+    name: test
+    options:
+        - name: config
+          type: string
+        - name: server
+          type: string
+          default: localhost
+          env: CLIX_CONFIG_TEST_SERVER
+        - name: port
+          type: integer
+          default: 80
+    commands:
+        - name: up
+          options:
+              - name: wait
+                type: boolean
+              - name: labels
+                type: string
+                list: true
+              - name: vars
+                type: dict
+`
+
+const testConfig = `---
+# comments
+server: config-server
+port: 8080
+up:
+    wait: true
+    labels: [a, b]
+    vars:
+        a: 1
+`
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	fn := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func loadCli(t *testing.T) *flag.CliDef {
+	cli, err := flag.DecodeCliDefString(testCmdDef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cli
+}
+
+func TestConfigFile(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "clix-config")
+	if !a.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	fn := writeConfig(t, dir, "test.yaml", testConfig)
+
+	r := loadCli(t).
+		Use(NewExt().NoSearch().Load(fn)).
+		ParseArgs("test", "--port=9090", "up")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.False(r.HasErrors())
+		a.Equal("config-server", r.CmdStack[0].Vars["server"])
+		a.Equal(int64(9090), r.CmdStack[0].Vars["port"])
+		cs := r.CmdStack[1]
+		a.Equal(true, cs.Vars["wait"])
+		a.Equal([]interface{}{"a", "b"}, cs.Vars["labels"])
+		a.Equal(map[string]interface{}{"a": 1}, cs.Vars["vars"])
+	}
+
+	os.Setenv("CLIX_CONFIG_TEST_SERVER", "env-server")
+	defer os.Unsetenv("CLIX_CONFIG_TEST_SERVER")
+	r = loadCli(t).
+		Use(NewExt().NoSearch().Load(fn)).
+		ParseArgs("test", "up")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.Equal("env-server", r.CmdStack[0].Vars["server"])
+		a.Equal(int64(8080), r.CmdStack[0].Vars["port"])
+	}
+}
+
+func TestConfigFileOpt(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "clix-config")
+	if !a.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	fn := writeConfig(t, dir, "test.json", `{
+    "port": 8081,
+    "up": {
+        "wait": true
+    }
+}`)
+
+	r := loadCli(t).
+		Use(NewExt().NoSearch().FileOption("config")).
+		ParseArgs("test", "up", "--config="+fn)
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.False(r.HasErrors())
+		a.Equal(int64(8081), r.CmdStack[0].Vars["port"])
+		a.Equal(true, r.CmdStack[1].Vars["wait"])
+	}
+
+	r = loadCli(t).
+		Use(NewExt().NoSearch().FileOption("config")).
+		ParseArgs("test", "--config="+filepath.Join(dir, "missing.yaml"), "up")
+	a.Error(r.Error)
+}
+
+func TestConfigSearch(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "clix-config")
+	if !a.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "home", "test"), 0755)
+	writeConfig(t, filepath.Join(dir, "home", "test"), "config.yml", "port: 1\nserver: home\n")
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "etc"))
+	defer os.Unsetenv("XDG_CONFIG_DIRS")
+	fn := writeConfig(t, dir, "test.yaml", "port: 2\n")
+
+	x := NewExt().Load(fn)
+	x.Cwd = false
+	r := loadCli(t).Use(x).ParseArgs("test")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 1) {
+		a.False(r.HasErrors())
+		a.Equal("home", r.CmdStack[0].Vars["server"])
+		a.Equal(int64(2), r.CmdStack[0].Vars["port"])
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "clix-config")
+	if !a.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	fn := writeConfig(t, dir, "test.yaml", `---
+port: not-int
+unknown: 1
+up:
+    # comment
+    unknown: 2
+    wait: true
+`)
+
+	r := loadCli(t).
+		Use(NewExt().NoSearch().Load(fn)).
+		ParseArgs("test", "up")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.True(r.HasErrors())
+		errs := r.CmdStack[0].Errs
+		if a.Len(errs, 2) {
+			a.Equal("port", errs[0].Name)
+			a.Equal(flag.VarErrBadVal, errs[0].ErrType)
+			a.Equal(fn, errs[0].File)
+			a.Equal(2, errs[0].Line)
+			a.Equal("unknown", errs[1].Name)
+			a.Equal(flag.VarErrNoDef, errs[1].ErrType)
+			a.Equal(3, errs[1].Line)
+		}
+		errs = r.CmdStack[1].Errs
+		if a.Len(errs, 1) {
+			a.Equal("unknown", errs[0].Name)
+			a.Equal(flag.VarErrNoDef, errs[0].ErrType)
+			a.Equal(6, errs[0].Line)
+		}
+	}
+
+	fn = writeConfig(t, dir, "bad.yaml", "port: [")
+	r = loadCli(t).
+		Use(NewExt().NoSearch().Load(fn)).
+		ParseArgs("test", "up")
+	a.Error(r.Error)
+}
@@ -0,0 +1,114 @@
+package deprecate
+
+import (
+	"strings"
+
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/codingbrain/clix.go/term"
+)
+
+// DeprecateExt defines the deprecate extension which warns about the use
+// of deprecated commands and options, and must be hooked up to
+// - EvtStartCmd
+// - EvtAssignOpt
+//
+// The value of a deprecated option is forwarded to the option replacing it.
+type DeprecateExt struct {
+	Terminal *term.Terminal
+
+	warned map[interface{}]bool
+}
+
+// NewExt creates deprecate extension
+func NewExt() *DeprecateExt {
+	return &DeprecateExt{Terminal: term.Std}
+}
+
+// UseTerminal explicitly specifies the terminal
+func (x *DeprecateExt) UseTerminal(t *term.Terminal) *DeprecateExt {
+	x.Terminal = t
+	return x
+}
+
+// HandleParseEvent implements parse extension
+func (x *DeprecateExt) HandleParseEvent(event string, ctx *flag.ParseContext) {
+	switch event {
+	case flag.EvtStartCmd:
+		cmd := ctx.CurrentCmd().Cmd
+		if cmd.IsDeprecated() {
+			var repl string
+			if cmd.ReplacedBy != "" {
+				repl = "use " + cmd.ReplacedBy + " instead"
+			}
+			x.warn(cmd, "command "+cmd.Name, cmd.Deprecated, repl)
+		}
+	case flag.EvtAssignOpt:
+		opt := ctx.Option
+		if opt == nil || !opt.IsDeprecated() {
+			return
+		}
+		var repl string
+		if opt.ReplacedBy != "" {
+			repl = "use " + optName(opt.ReplacedBy) + " instead"
+		}
+		x.warn(opt, displayName(opt), opt.Deprecated, repl)
+		if opt.ReplacedBy != "" {
+			x.forward(ctx, opt.ReplacedBy)
+		}
+	}
+}
+
+// RegisterExt implements ExtRegistrar
+func (x *DeprecateExt) RegisterExt(parser *flag.Parser) {
+	parser.AddParseExt(flag.EvtStartCmd, x)
+	parser.AddParseExt(flag.EvtAssignOpt, x)
+	x.warned = make(map[interface{}]bool)
+}
+
+// warn displays the warning once for each command or option
+func (x *DeprecateExt) warn(item interface{}, name, msg, repl string) {
+	if x.warned[item] {
+		return
+	}
+	x.warned[item] = true
+	warning := "WARNING: " + name + " is deprecated"
+	if repl != "" {
+		warning += ", " + repl
+	}
+	if msg != "" {
+		warning += ": " + msg
+	}
+	t := x.Terminal
+	if t == nil {
+		t = term.Std
+	}
+	t.Warnln(warning)
+}
+
+// optName formats the name of option as used on the command line
+func optName(name string) string {
+	if len(name) > 1 {
+		return "--" + name
+	}
+	return "-" + name
+}
+
+// displayName formats the name of option, or argument in upper case
+func displayName(opt *flag.Option) string {
+	if opt.IsArg {
+		return strings.ToUpper(opt.Name)
+	}
+	return optName(opt.Name)
+}
+
+// forward redirects the value to the replacing option which is defined
+// in the same command or inherited from parent commands
+func (x *DeprecateExt) forward(ctx *flag.ParseContext, name string) {
+	for at := ctx.OptionAt; at >= 0; at-- {
+		opt := ctx.CmdAt(at).Cmd.FindOption(name)
+		if opt != nil && (at == ctx.OptionAt || !opt.Local) {
+			ctx.Option, ctx.OptionAt, ctx.Name = opt, at, opt.Name
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package deprecate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/codingbrain/clix.go/term"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCmdDef = `---
+cli:
+    name: test
+    options:
+        - name: output
+          alias: [o]
+        - name: out
+          deprecated: renamed for consistency
+          replaced-by: output
+    commands:
+        - name: list
+          options:
+              - name: all
+                type: bool
+              - name: everything
+                type: bool
+                hidden: true
+                replaced-by: all
+        - name: ls
+          hidden: true
+          replaced-by: list
+`
+
+func runParser(t *testing.T, args ...string) (string, *flag.ParseResult) {
+	cli, err := flag.DecodeCliDefString(testCmdDef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	r := cli.Use(NewExt().UseTerminal(&term.Terminal{Out: &buf})).ParseArgs(args...)
+	return buf.String(), r
+}
+
+func TestDeprecatedOptions(t *testing.T) {
+	a := assert.New(t)
+	out, r := runParser(t, "test", "--out=a", "list", "--out", "b", "--everything")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal("b", r.CmdStack[0].Vars["output"])
+		a.Equal("b", r.CmdStack[0].Opts["output"])
+		a.NotContains(r.CmdStack[0].Opts, "out")
+		a.True(r.CmdStack[1].Vars["all"].(bool))
+		a.Equal("WARNING: --out is deprecated, use --output instead: renamed for consistency\n"+
+			"WARNING: --everything is deprecated, use --all instead\n", out)
+	}
+
+	out, r = runParser(t, "test", "-o", "a", "list", "--all")
+	if a.False(r.HasErrors()) {
+		a.Empty(out)
+	}
+}
+
+func TestDeprecatedCommands(t *testing.T) {
+	a := assert.New(t)
+	out, r := runParser(t, "test", "ls")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal("ls", r.CmdStack[1].Cmd.Name)
+		a.Equal("WARNING: command ls is deprecated, use list instead\n", out)
+	}
+}
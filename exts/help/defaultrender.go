@@ -1,6 +1,7 @@
 package help
 
 import (
+	"fmt"
 	"io"
 	"strings"
 
@@ -8,6 +9,8 @@ import (
 	"github.com/codingbrain/clix.go/term"
 )
 
+const deprecatedNote = " (deprecated)"
+
 type DefaultRender struct {
 	Output io.Writer
 	Plain  bool // no styles
@@ -89,16 +92,24 @@ func (r *DefaultRender) RenderUsage(info *UsageInfo) {
 }
 
 func (r *DefaultRender) RenderCommands(cmds []*flag.Command) {
+	r.RenderCommandSection("Commands", cmds)
+}
+
+func (r *DefaultRender) RenderCommandSection(title string, cmds []*flag.Command) {
 	cr := &twoColRender{}
 	for _, cmd := range cmds {
 		name := strings.Join(append([]string{cmd.Name}, cmd.Alias...), "|")
-		cr.rows = append(cr.rows, &twoColRow{col: []string{name, cmd.Desc}})
+		desc := cmd.Desc
+		if cmd.IsDeprecated() {
+			desc += deprecatedNote
+		}
+		cr.rows = append(cr.rows, &twoColRow{col: []string{name, desc}})
 		if cmd.Example != "" {
 			cr.rows = append(cr.rows, &twoColRow{col: []string{"", cmd.Example}})
 		}
 	}
 	printer := r.printer()
-	printer.Styles(term.StyleHi, term.StyleI).Print("Commands").Reset().Println(":")
+	printer.Styles(term.StyleHi, term.StyleI).Print(title).Reset().Println(":")
 	cr.render(printer)
 	printer.Println()
 }
@@ -106,7 +117,11 @@ func (r *DefaultRender) RenderCommands(cmds []*flag.Command) {
 func (r *DefaultRender) RenderArguments(opts []*flag.Option) {
 	cr := &twoColRender{}
 	for _, opt := range opts {
-		cr.rows = append(cr.rows, &twoColRow{col: []string{ArgDisplayName(opt), opt.Desc}})
+		desc := opt.Desc + valueRules(opt)
+		if opt.Env != "" {
+			desc += " ($" + opt.Env + ")"
+		}
+		cr.rows = append(cr.rows, &twoColRow{col: []string{ArgDisplayName(opt), desc}})
 		if opt.Example != "" {
 			cr.rows = append(cr.rows, &twoColRow{col: []string{"", opt.Example}})
 		}
@@ -117,7 +132,31 @@ func (r *DefaultRender) RenderArguments(opts []*flag.Option) {
 	printer.Println()
 }
 
+// valueRules describes the allowed values
+func valueRules(opt *flag.Option) string {
+	var desc string
+	if len(opt.Choices) > 0 {
+		desc += " {" + strings.Join(opt.ChoiceStrings(), "|") + "}"
+	}
+	if opt.Min != nil && opt.Max != nil {
+		desc += fmt.Sprintf(" (%v..%v)", opt.Min, opt.Max)
+	} else if opt.Min != nil {
+		desc += fmt.Sprintf(" (>= %v)", opt.Min)
+	} else if opt.Max != nil {
+		desc += fmt.Sprintf(" (<= %v)", opt.Max)
+	}
+	return desc
+}
+
 func (r *DefaultRender) RenderOptions(opts []*flag.Option) {
+	r.renderOptions("Options", opts)
+}
+
+func (r *DefaultRender) RenderInheritedOptions(opts []*flag.Option) {
+	r.renderOptions("Inherited Options", opts)
+}
+
+func (r *DefaultRender) renderOptions(title string, opts []*flag.Option) {
 	cr := &twoColRender{}
 	for _, opt := range opts {
 		var short, long []string
@@ -147,18 +186,27 @@ func (r *DefaultRender) RenderOptions(opts []*flag.Option) {
 		}
 		if opt.Required {
 			row.col[1] = "<Required> " + opt.Desc
+		} else if opt.DefaultDesc != "" {
+			row.col[1] = "[" + opt.DefaultDesc + "] " + opt.Desc
 		} else if defVal := opt.DefaultAsString(); defVal != "" {
 			row.col[1] = "[" + defVal + "] " + opt.Desc
 		} else {
 			row.col[1] = opt.Desc
 		}
+		row.col[1] += valueRules(opt)
+		if opt.Env != "" {
+			row.col[1] += " ($" + opt.Env + ")"
+		}
+		if opt.IsDeprecated() {
+			row.col[1] += deprecatedNote
+		}
 		cr.rows = append(cr.rows, row)
 		if opt.Example != "" {
 			cr.rows = append(cr.rows, &twoColRow{col: []string{"", opt.Example}})
 		}
 	}
 	printer := r.printer()
-	printer.Styles(term.StyleHi, term.StyleI).Print("Options").Reset().Println(":")
+	printer.Styles(term.StyleHi, term.StyleI).Print(title).Reset().Println(":")
 	cr.render(printer)
 	printer.Println()
 }
@@ -166,6 +214,11 @@ func (r *DefaultRender) RenderOptions(opts []*flag.Option) {
 func (r *DefaultRender) RenderErrors(errs []*ErrInfo) {
 	for _, err := range errs {
 		r.printer().Styles(term.StyleErr).Println("ERROR: " + err.Msg).Reset()
+		if l := len(err.Suggestions); l == 1 {
+			r.printer().Println("Did you mean " + err.Suggestions[0] + "?")
+		} else if l > 1 {
+			r.printer().Println("Did you mean one of " + strings.Join(err.Suggestions, ", ") + "?")
+		}
 	}
 }
 
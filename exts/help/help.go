@@ -3,6 +3,7 @@ package help
 import (
 	"errors"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/codingbrain/clix.go/flag"
@@ -17,6 +18,11 @@ var (
 	DefaultLong = "help"
 	// DefaultAlias defines the default alias options for help
 	DefaultAlias = []string{"h", "?"}
+	// DefaultAllLong defines the default long option for help
+	// including hidden commands and options
+	DefaultAllLong = "help-all"
+	// DefaultSuggestDistance is the default max edit distance for suggestions
+	DefaultSuggestDistance = 2
 
 	// ErrorHelp is used as error when help is displayed
 	ErrorHelp = errors.New("help requested")
@@ -36,6 +42,8 @@ type ErrInfo struct {
 	Cmd string
 	// present indicate a parsing error
 	Var *flag.VarError
+	// similar names of unknown command or option
+	Suggestions []string
 }
 
 // UsageInfo defines the information to be displayed in usage line
@@ -66,28 +74,61 @@ type HelpRender interface {
 	RenderErrors([]*ErrInfo)
 }
 
+// CommandSectionRender is optionally implemented by a HelpRender to
+// display the commands from CommandsSource under their own titles,
+// otherwise they are displayed with the defined commands
+type CommandSectionRender interface {
+	// RenderCommandSection displays commands under a specific title
+	RenderCommandSection(string, []*flag.Command)
+}
+
+// InheritedOptionsRender is optionally implemented by a HelpRender to
+// display the options inherited from parent commands separately,
+// otherwise they are displayed with the options of the command
+type InheritedOptionsRender interface {
+	// RenderInheritedOptions displays options inherited from parent commands
+	RenderInheritedOptions([]*flag.Option)
+}
+
+// CommandsSource provides the commands which are not defined in CliDef
+// but resolved by other extensions, e.g. aliases, to be displayed in help
+type CommandsSource interface {
+	// HelpCommands returns the commands available under the last command
+	// in cmdPath, and the title of the section displaying them, or empty
+	// title to display them with the defined commands
+	HelpCommands(cmdPath []*flag.Command) (string, []*flag.Command)
+}
+
 // HelpExt defines the help extension which must be hooked up to
 // - EvtResoveOpt
 // - Execution
 type HelpExt struct {
 	Long         string
 	Alias        []string
+	AllLong      string
 	Render       HelpRender
 	HelpExitCode int
 	ErrExitCode  int
+	// SuggestDistance is the max edit distance of suggested names for
+	// unknown command or option, suggestions are disabled if <= 0
+	SuggestDistance int
 
 	helpCmdAt int
+	all       bool
+	sources   []CommandsSource
 }
 
 // NewExt creates help extension
 func NewExt() *HelpExt {
 	return &HelpExt{
-		Long:         DefaultLong,
-		Alias:        DefaultAlias,
-		Render:       &DefaultRender{},
-		HelpExitCode: 2,
-		ErrExitCode:  1,
-		helpCmdAt:    -1,
+		Long:            DefaultLong,
+		Alias:           DefaultAlias,
+		AllLong:         DefaultAllLong,
+		Render:          &DefaultRender{},
+		HelpExitCode:    2,
+		ErrExitCode:     1,
+		SuggestDistance: DefaultSuggestDistance,
+		helpCmdAt:       -1,
 	}
 }
 
@@ -98,6 +139,19 @@ func (x *HelpExt) OptNames(long string, alias ...string) *HelpExt {
 	return x
 }
 
+// AllOptName overrides the help option which displays hidden commands
+// and options as well, use empty string to disable it
+func (x *HelpExt) AllOptName(long string) *HelpExt {
+	x.AllLong = long
+	return x
+}
+
+// ShowCommands displays the commands from the sources in help
+func (x *HelpExt) ShowCommands(sources ...CommandsSource) *HelpExt {
+	x.sources = append(x.sources, sources...)
+	return x
+}
+
 // UseRender sets the render for help information
 func (x *HelpExt) UseRender(render HelpRender) *HelpExt {
 	x.Render = render
@@ -119,6 +173,18 @@ func (x *HelpExt) ExitWith(helpCode, errCode int) *HelpExt {
 	return x
 }
 
+// Suggest specifies the max edit distance of suggestions,
+// use 0 to disable suggestions
+func (x *HelpExt) Suggest(distance int) *HelpExt {
+	x.SuggestDistance = distance
+	return x
+}
+
+// NoSuggest disables suggestions for unknown command or option
+func (x *HelpExt) NoSuggest() *HelpExt {
+	return x.Suggest(0)
+}
+
 // ExecuteCmd implements execution extension
 func (x *HelpExt) ExecuteCmd(ctx *flag.ExecContext) {
 	err := ctx.Result.Error
@@ -141,12 +207,21 @@ func (x *HelpExt) ExecuteCmd(ctx *flag.ExecContext) {
 
 	x.RenderStart()
 	if ctx.Result.MissingCmd {
-		x.displayErrors([]*ErrInfo{&ErrInfo{Cmd: ctx.Result.UnparsedArgs[0]}})
+		name := ctx.Result.UnparsedArgs[0]
+		err := &ErrInfo{Cmd: name}
+		if x.SuggestDistance > 0 {
+			err.Suggestions = ctx.Cmd().Cmd.SuggestCommands(name, x.SuggestDistance)
+		}
+		x.displayErrors([]*ErrInfo{err})
 	} else if !ctx.Result.ExpectCmd {
 		errs := make([]*ErrInfo, 0, 0)
-		for _, pcmd := range ctx.Result.CmdStack {
+		for at, pcmd := range ctx.Result.CmdStack {
 			for _, e := range pcmd.Errs {
-				errs = append(errs, &ErrInfo{Var: e})
+				err := &ErrInfo{Var: e}
+				if e.ErrType == flag.VarErrNoDef && x.SuggestDistance > 0 {
+					err.Suggestions = x.suggestOptions(ctx.Result.CmdStack[:at+1], e.Name)
+				}
+				errs = append(errs, err)
 			}
 		}
 		if len(errs) == 0 {
@@ -166,7 +241,9 @@ func (x *HelpExt) HandleParseEvent(event string, ctx *flag.ParseContext) {
 	if event != flag.EvtResolveOpt || x.helpCmdAt >= 0 || ctx.Name == "" {
 		return
 	}
-	if ctx.Name != x.Long {
+	if ctx.Name == x.AllLong {
+		x.all = true
+	} else if ctx.Name != x.Long {
 		found := false
 		for _, a := range x.Alias {
 			if ctx.Name == a {
@@ -189,6 +266,7 @@ func (x *HelpExt) RegisterExt(parser *flag.Parser) {
 	parser.AddParseExt(flag.EvtResolveOpt, x)
 	parser.AddExecExt(x)
 	x.helpCmdAt = -1
+	x.all = false
 }
 
 // RenderStart self implements HelpRender
@@ -226,6 +304,13 @@ func (x *HelpExt) RenderCommands(cmds []*flag.Command) {
 	}
 }
 
+// RenderCommandSection self implements CommandSectionRender
+func (x *HelpExt) RenderCommandSection(title string, cmds []*flag.Command) {
+	if r, ok := x.Render.(CommandSectionRender); ok {
+		r.RenderCommandSection(title, cmds)
+	}
+}
+
 // RenderArguments self implements HelpRender
 func (x *HelpExt) RenderArguments(opts []*flag.Option) {
 	if x.Render != nil {
@@ -240,6 +325,13 @@ func (x *HelpExt) RenderOptions(opts []*flag.Option) {
 	}
 }
 
+// RenderInheritedOptions self implements InheritedOptionsRender
+func (x *HelpExt) RenderInheritedOptions(opts []*flag.Option) {
+	if r, ok := x.Render.(InheritedOptionsRender); ok {
+		r.RenderInheritedOptions(opts)
+	}
+}
+
 // RenderErrors self implements HelpRender
 func (x *HelpExt) RenderErrors(errs []*ErrInfo) {
 	if x.Render != nil {
@@ -247,6 +339,28 @@ func (x *HelpExt) RenderErrors(errs []*ErrInfo) {
 	}
 }
 
+func (x *HelpExt) suggestOptions(stack []*flag.ParsedCmd, name string) []string {
+	var names []string
+	for at, pcmd := range stack {
+		for _, n := range pcmd.Cmd.SuggestOptions(name, x.SuggestDistance) {
+			opt := pcmd.Cmd.FindOption(n)
+			if opt == nil {
+				opt = pcmd.Cmd.FindOption(strings.TrimPrefix(n, "no-"))
+			}
+			if at == len(stack)-1 || !opt.Local {
+				names = append(names, n)
+			}
+		}
+	}
+	if len(stack) > 1 {
+		names = flag.Suggest(name, names, x.SuggestDistance)
+	}
+	for i, n := range names {
+		names[i] = OptName(n)
+	}
+	return names
+}
+
 func (x *HelpExt) displayHelp(stack []*flag.ParsedCmd, at int, banner bool) {
 	if banner {
 		pcmd := stack[0]
@@ -256,21 +370,33 @@ func (x *HelpExt) displayHelp(stack []*flag.ParsedCmd, at int, banner bool) {
 	}
 
 	usage := &UsageInfo{}
-	optCount := 0
+	var inherited []*flag.Option
 	for i := 0; i <= at; i++ {
 		pcmd := stack[i]
 		usage.Cmds = append(usage.Cmds, pcmd.Cmd.Name)
-		optCount += len(pcmd.Cmd.Options)
+		if i == at {
+			break
+		}
+		for _, opt := range x.visibleOptions(pcmd.Cmd.Options) {
+			if !opt.Local {
+				inherited = append(inherited, opt)
+			}
+		}
 	}
-	if optCount > 0 {
+	pcmd := stack[at]
+	opts := x.visibleOptions(pcmd.Cmd.Options)
+	cmds := x.visibleCommands(pcmd.Cmd.Commands)
+	if len(opts) > 0 || len(inherited) > 0 {
 		usage.Opts = []string{"[OPTIONS]"}
 	}
-	pcmd := stack[at]
 	if len(pcmd.Cmd.Commands) > 0 {
 		usage.Args = []string{"SUBCOMMAND"}
 	} else {
 		for _, arg := range pcmd.Cmd.Arguments {
 			name := ArgDisplayName(arg)
+			if arg.List {
+				name += "..."
+			}
 			if !arg.Required {
 				name = "[" + name + "]"
 			}
@@ -282,19 +408,73 @@ func (x *HelpExt) displayHelp(stack []*flag.ParsedCmd, at int, banner bool) {
 	x.RenderUsage(usage)
 
 	if len(pcmd.Cmd.Commands) > 0 {
-		x.RenderCommands(pcmd.Cmd.Commands)
+		cmdPath := make([]*flag.Command, at+1)
+		for i := range cmdPath {
+			cmdPath[i] = stack[i].Cmd
+		}
+		var titles []string
+		sections := make(map[string][]*flag.Command)
+		_, withSections := x.Render.(CommandSectionRender)
+		for _, src := range x.sources {
+			title, more := src.HelpCommands(cmdPath)
+			more = x.visibleCommands(more)
+			if title == "" || !withSections {
+				cmds = append(cmds, more...)
+			} else if len(more) > 0 {
+				if _, exists := sections[title]; !exists {
+					titles = append(titles, title)
+				}
+				sections[title] = append(sections[title], more...)
+			}
+		}
+		if len(cmds) > 0 {
+			x.RenderCommands(cmds)
+		}
+		for _, title := range titles {
+			x.RenderCommandSection(title, sections[title])
+		}
 	} else if len(pcmd.Cmd.Arguments) > 0 {
 		x.RenderArguments(pcmd.Cmd.Arguments)
 	}
 
-	var opts []*flag.Option
-	for i := 0; i <= at; i++ {
-		pcmd := stack[i]
-		opts = append(opts, pcmd.Cmd.Options...)
+	_, withInherited := x.Render.(InheritedOptionsRender)
+	if !withInherited {
+		opts = append(opts, inherited...)
 	}
 	if len(opts) > 0 {
 		x.RenderOptions(opts)
 	}
+	if withInherited && len(inherited) > 0 {
+		x.RenderInheritedOptions(inherited)
+	}
+}
+
+// visibleOptions filters out hidden options unless all is requested
+func (x *HelpExt) visibleOptions(opts []*flag.Option) []*flag.Option {
+	if x.all {
+		return opts
+	}
+	var visible []*flag.Option
+	for _, opt := range opts {
+		if !opt.Hidden {
+			visible = append(visible, opt)
+		}
+	}
+	return visible
+}
+
+// visibleCommands filters out hidden commands unless all is requested
+func (x *HelpExt) visibleCommands(cmds []*flag.Command) []*flag.Command {
+	if x.all {
+		return cmds
+	}
+	var visible []*flag.Command
+	for _, cmd := range cmds {
+		if !cmd.Hidden {
+			visible = append(visible, cmd)
+		}
+	}
+	return visible
 }
 
 func (x *HelpExt) displayErrors(errs []*ErrInfo) {
@@ -311,6 +491,9 @@ func (x *HelpExt) displayErrors(errs []*ErrInfo) {
 				} else {
 					err.Msg = "require option " + OptName(err.Var.Name)
 				}
+				if err.Var.Reason != "" {
+					err.Msg += " (" + err.Var.Reason + ")"
+				}
 			case flag.VarErrBadVal:
 				if err.Var.Def.IsArg {
 					err.Msg = "invalid value for argument " + ArgDisplayName(err.Var.Def)
@@ -320,6 +503,22 @@ func (x *HelpExt) displayErrors(errs []*ErrInfo) {
 				if err.Var.Value != nil {
 					err.Msg += ": " + *err.Var.Value
 				}
+				if err.Var.Reason != "" {
+					err.Msg += " (" + err.Var.Reason + ")"
+				}
+			case flag.VarErrExclusive:
+				err.Msg = OptName(err.Var.Name) + " can't be used with " + optNames(err.Var.Peers)
+			case flag.VarErrRequires:
+				err.Msg = OptName(err.Var.Name) + " requires " + optNames(err.Var.Peers)
+			case flag.VarErrAtLeastOne:
+				err.Msg = "require one of " +
+					optNames(append([]string{err.Var.Name}, err.Var.Peers...))
+			case flag.VarErrAmbiguous:
+				err.Msg = "ambiguous option " + OptName(err.Var.Name) +
+					", could be " + optNames(err.Var.Peers)
+			}
+			if err.Var.File != "" {
+				err.Msg = VarErrLocation(err.Var) + ": " + err.Msg
 			}
 		}
 	}
@@ -328,6 +527,14 @@ func (x *HelpExt) displayErrors(errs []*ErrInfo) {
 	}
 }
 
+// VarErrLocation formats the location of value which is not from command line
+func VarErrLocation(err *flag.VarError) string {
+	if err.Line > 0 {
+		return err.File + ":" + strconv.Itoa(err.Line)
+	}
+	return err.File
+}
+
 func OptName(name string) string {
 	if len(name) > 1 {
 		return "--" + name
@@ -335,6 +542,14 @@ func OptName(name string) string {
 	return "-" + name
 }
 
+func optNames(names []string) string {
+	strs := make([]string, len(names))
+	for i, name := range names {
+		strs[i] = OptName(name)
+	}
+	return strings.Join(strs, ", ")
+}
+
 func OptVarName(opt *flag.Option) string {
 	if v, exist := opt.TagString(TagVar); exist && v != "" {
 		return v
@@ -1,6 +1,8 @@
 package help
 
 import (
+	"bytes"
+	"os"
 	"regexp"
 	"testing"
 
@@ -16,6 +18,9 @@ type testRender struct {
 	args   []*flag.Option
 	errs   []*ErrInfo
 
+	inherited []*flag.Option
+	sections  map[string][]*flag.Command
+
 	fwd HelpRender
 }
 
@@ -52,6 +57,16 @@ func (r *testRender) RenderCommands(cmds []*flag.Command) {
 	}
 }
 
+func (r *testRender) RenderCommandSection(title string, cmds []*flag.Command) {
+	if r.sections == nil {
+		r.sections = make(map[string][]*flag.Command)
+	}
+	r.sections[title] = cmds
+	if fwd, ok := r.fwd.(CommandSectionRender); ok {
+		fwd.RenderCommandSection(title, cmds)
+	}
+}
+
 func (r *testRender) RenderOptions(opts []*flag.Option) {
 	r.opts = opts
 	if r.fwd != nil {
@@ -59,6 +74,13 @@ func (r *testRender) RenderOptions(opts []*flag.Option) {
 	}
 }
 
+func (r *testRender) RenderInheritedOptions(opts []*flag.Option) {
+	r.inherited = opts
+	if fwd, ok := r.fwd.(InheritedOptionsRender); ok {
+		fwd.RenderInheritedOptions(opts)
+	}
+}
+
 func (r *testRender) RenderArguments(opts []*flag.Option) {
 	r.args = opts
 	if r.fwd != nil {
@@ -73,6 +95,11 @@ func (r *testRender) RenderErrors(errs []*ErrInfo) {
 	}
 }
 
+// basicRender only exposes the methods of HelpRender
+type basicRender struct {
+	HelpRender
+}
+
 const (
 	testCmdDef1 = `---
     cli:
@@ -169,7 +196,7 @@ func TestHelpArguments(t *testing.T) {
 			}
 			if a.Len(render.usage.Args, 2) {
 				a.Equal("C2A1", render.usage.Args[0])
-				a.Equal("[C2A2]", render.usage.Args[1])
+				a.Equal("[C2A2...]", render.usage.Args[1])
 			}
 		}
 		a.Equal([]string{"test", "c2"}, render.usage.Cmds)
@@ -206,6 +233,103 @@ func TestHelpNoDef(t *testing.T) {
 	}
 }
 
+func TestHelpSuggest(t *testing.T) {
+	a := assert.New(t)
+	render, _ := runParser(t, testCmdDef1, "test", "c4")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Equal([]string{"c1", "c2", "c3"}, render.errs[0].Suggestions)
+	}
+	render, _ = runParser(t, testCmdDef1, "test", "c1", "--c1o2", "c1s1")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Equal([]string{"--c1o1"}, render.errs[0].Suggestions)
+	}
+	render, _ = runParser(t, testCmdDef1, "test", "c1", "--o2", "c1s1")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Equal([]string{"--o1"}, render.errs[0].Suggestions)
+	}
+	render, _ = runParser(t, testCmdDef1, "test", "c2", "--unknown", "c2a1")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Empty(render.errs[0].Suggestions)
+	}
+
+	cli, err := flag.DecodeCliDefString(testCmdDef1)
+	if a.NoError(err) {
+		render = &testRender{}
+		err = cli.Use(NewExt().UseRender(render).NoExit().NoSuggest()).
+			ParseArgs("test", "c4").Exec()
+		a.Equal(ErrorHelp, err)
+		if a.Len(render.errs, 1) {
+			a.Empty(render.errs[0].Suggestions)
+		}
+	}
+}
+
+func TestHelpConstraints(t *testing.T) {
+	a := assert.New(t)
+	cmdDef := `---
+    cli:
+      name: test
+      options:
+        - name: json
+          type: bool
+        - name: yaml
+          type: bool
+        - name: key
+        - name: cert
+      exclusive:
+        - [json, yaml]
+      requires:
+        key: [cert]
+      at-least-one:
+        - [json, yaml]
+    `
+	render, _ := runParser(t, cmdDef, "test", "--json", "--yaml", "--key=k")
+	if a.NotNil(render) && a.Len(render.errs, 2) {
+		a.Equal("--json can't be used with --yaml", render.errs[0].Msg)
+		a.Equal("--key requires --cert", render.errs[1].Msg)
+	}
+	render, _ = runParser(t, cmdDef, "test")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Equal("require one of --json, --yaml", render.errs[0].Msg)
+	}
+}
+
+func TestHelpBadValReason(t *testing.T) {
+	a := assert.New(t)
+	cmdDef := `---
+    cli:
+      name: test
+      options:
+        - name: format
+          choices: [json, yaml]
+    `
+	render, _ := runParser(t, cmdDef, "test", "--format=xml")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Equal("invalid value for --format: xml (must be one of json, yaml)", render.errs[0].Msg)
+	}
+}
+
+func TestHelpListArgCount(t *testing.T) {
+	a := assert.New(t)
+	cmdDef := `---
+    cli:
+      name: test
+      arguments:
+        - name: file
+          list: true
+          min-count: 2
+          max-count: 3
+    `
+	render, _ := runParser(t, cmdDef, "test", "f1")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Equal("require argument FILE (expect at least 2 values)", render.errs[0].Msg)
+	}
+	render, _ = runParser(t, cmdDef, "test", "f1", "f2", "f3", "f4")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Equal("invalid value for argument FILE: f4 (expect at most 3 values)", render.errs[0].Msg)
+	}
+}
+
 func TestHelpOptNoVal(t *testing.T) {
 	a := assert.New(t)
 	render, _ := runParser(t, testCmdDef1, "test", "c3", "--c3o1", "-3")
@@ -283,3 +407,178 @@ func TestCustomHelpOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestHelpAmbiguousOpt(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(`---
+cli:
+    name: test
+    options:
+        - name: verbose
+          type: bool
+        - name: version
+          type: bool
+    `)
+	if a.NoError(err) {
+		render := &testRender{}
+		err = cli.Use(NewExt().UseRender(render).NoExit()).
+			Parser().PrefixMatch(true).
+			ParseArgs("test", "--ver").
+			Exec()
+		a.Equal(ErrorHelp, err)
+		if a.Len(render.errs, 1) {
+			a.Equal("ambiguous option --ver, could be --verbose, --version", render.errs[0].Msg)
+		}
+	}
+}
+
+func TestHelpInheritedOptions(t *testing.T) {
+	a := assert.New(t)
+	cmdDef := `---
+cli:
+    name: test
+    options:
+        - name: server
+        - name: force
+          type: bool
+          local: true
+    commands:
+        - name: status
+          options:
+              - name: short
+                type: bool
+    `
+	render, _ := runParser(t, cmdDef, "test", "status", "--help")
+	if a.NotNil(render) {
+		if a.Len(render.opts, 1) {
+			a.Equal("short", render.opts[0].Name)
+		}
+		if a.Len(render.inherited, 1) {
+			a.Equal("server", render.inherited[0].Name)
+		}
+	}
+	render, _ = runParser(t, cmdDef, "test", "status", "--forc")
+	if a.NotNil(render) && a.Len(render.errs, 1) {
+		a.Equal("unknown option: forc", render.errs[0].Msg)
+		a.Empty(render.errs[0].Suggestions)
+	}
+
+	// a render only implementing HelpRender gets inherited options with others
+	cli, err := flag.DecodeCliDefString(cmdDef)
+	if a.NoError(err) {
+		render := &testRender{}
+		err = cli.Use(NewExt().UseRender(basicRender{render}).NoExit()).
+			ParseArgs("test", "status", "--help").Exec()
+		a.Equal(ErrorHelp, err)
+		if a.Len(render.opts, 2) {
+			a.Equal("short", render.opts[0].Name)
+			a.Equal("server", render.opts[1].Name)
+		}
+		a.Empty(render.inherited)
+	}
+}
+
+func TestHelpHidden(t *testing.T) {
+	a := assert.New(t)
+	cmdDef := `---
+cli:
+    name: test
+    options:
+        - name: debug
+          type: bool
+          hidden: true
+        - name: verbose
+          type: bool
+    commands:
+        - name: list
+        - name: ls
+          hidden: true
+    `
+	render, _ := runParser(t, cmdDef, "test", "--help")
+	if a.NotNil(render) {
+		if a.Len(render.opts, 1) {
+			a.Equal("verbose", render.opts[0].Name)
+		}
+		if a.Len(render.cmds, 1) {
+			a.Equal("list", render.cmds[0].Name)
+		}
+	}
+	render, _ = runParser(t, cmdDef, "test", "--help-all")
+	if a.NotNil(render) {
+		a.Len(render.opts, 2)
+		a.Len(render.cmds, 2)
+	}
+}
+
+func TestHelpComputedDefaults(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(`---
+cli:
+    name: test
+    options:
+        - name: cache
+          default: '${CLIX_TEST_HELP_DIR}/cache'
+        - name: branch
+          default-desc: current branch
+`)
+	if !a.NoError(err) {
+		return
+	}
+	os.Setenv("CLIX_TEST_HELP_DIR", "/var/tool")
+	defer os.Unsetenv("CLIX_TEST_HELP_DIR")
+	var buf bytes.Buffer
+	render := &DefaultRender{Output: &buf, Plain: true}
+	render.RenderOptions(cli.Cli.Options)
+	a.Contains(buf.String(), "[/var/tool/cache]")
+	a.Contains(buf.String(), "[current branch]")
+}
+
+type testCommandsSource struct {
+	title string
+	cmds  []*flag.Command
+}
+
+func (s *testCommandsSource) HelpCommands(cmdPath []*flag.Command) (string, []*flag.Command) {
+	if len(cmdPath) > 1 {
+		return s.title, nil
+	}
+	return s.title, s.cmds
+}
+
+func TestHelpCommandsSource(t *testing.T) {
+	a := assert.New(t)
+	cli, err := flag.DecodeCliDefString(testCmdDef1)
+	if !a.NoError(err) {
+		return
+	}
+	render := &testRender{fwd: &DefaultRender{}}
+	ext := NewExt().UseRender(render).NoExit().ShowCommands(
+		&testCommandsSource{cmds: []*flag.Command{&flag.Command{Name: "p1"}}},
+		&testCommandsSource{title: "Aliases", cmds: []*flag.Command{&flag.Command{Name: "a1", Desc: "c1 c1s1"}}})
+	err = cli.Use(ext).ParseArgs("test", "--help").Exec()
+	a.Equal(ErrorHelp, err)
+	if a.Len(render.cmds, 4) {
+		a.Equal("p1", render.cmds[3].Name)
+	}
+	if a.Len(render.sections["Aliases"], 1) {
+		a.Equal("a1", render.sections["Aliases"][0].Name)
+	}
+
+	render.sections = nil
+	err = cli.ParseArgs("test", "c1", "--help").Exec()
+	a.Equal(ErrorHelp, err)
+	a.Empty(render.sections)
+
+	// without sections, the commands are displayed with defined commands
+	cli, err = flag.DecodeCliDefString(testCmdDef1)
+	if a.NoError(err) {
+		render = &testRender{}
+		ext.UseRender(basicRender{render})
+		err = cli.Use(ext).ParseArgs("test", "--help").Exec()
+		a.Equal(ErrorHelp, err)
+		if a.Len(render.cmds, 5) {
+			a.Equal("a1", render.cmds[4].Name)
+		}
+		a.Empty(render.sections)
+	}
+}
@@ -0,0 +1,244 @@
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codingbrain/clix.go/flag"
+)
+
+// ExitError is the error when the plugin exits with non-zero code
+type ExitError struct {
+	Plugin string
+	Code   int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("%s exited with code %d", e.Plugin, e.Code)
+}
+
+// PluginExt defines the plugin extension which runs external executables
+// as subcommands, and must be hooked up to
+// - EvtResolveCmd
+// - Execution (before help extension)
+//
+// When a subcommand is not found, an executable named PROGRAM-NAME is
+// searched (for nested subcommands, PROGRAM-CMD-NAME), and all args after
+// the name are passed to the plugin as is. The options parsed before are
+// passed through environment variables named EnvPrefix + NAME, e.g.
+// --log-level is passed as PROGRAM_LOG_LEVEL. The plugin inherits stdio,
+// and the program exits with the exit code of the plugin.
+type PluginExt struct {
+	// Program overrides the prefix of plugin executables,
+	// default is the name of root command
+	Program string
+	// Dirs are searched for plugins instead of PATH if specified
+	Dirs []string
+	// EnvPrefix overrides the prefix of environment variables,
+	// default is PROGRAM_ in upper case
+	EnvPrefix string
+	// Exit indicates to exit the program with the exit code of the plugin,
+	// otherwise ExitError is returned in result if the code is not zero
+	Exit bool
+
+	plugin   string
+	pluginAt int
+}
+
+// NewExt creates plugin extension
+func NewExt() *PluginExt {
+	return &PluginExt{Exit: true, pluginAt: -1}
+}
+
+// ProgramName overrides the prefix of plugin executables
+func (x *PluginExt) ProgramName(name string) *PluginExt {
+	x.Program = name
+	return x
+}
+
+// SearchDirs specifies the directories to search plugins instead of PATH
+func (x *PluginExt) SearchDirs(dirs ...string) *PluginExt {
+	x.Dirs = append(x.Dirs, dirs...)
+	return x
+}
+
+// EnvVarPrefix overrides the prefix of environment variables for options
+func (x *PluginExt) EnvVarPrefix(prefix string) *PluginExt {
+	x.EnvPrefix = prefix
+	return x
+}
+
+// NoExit prevents the extension invoke os.Exit with the exit code of plugin
+// and returns ExitError in result if the code is not zero
+func (x *PluginExt) NoExit() *PluginExt {
+	x.Exit = false
+	return x
+}
+
+// HandleParseEvent implements parse extension
+func (x *PluginExt) HandleParseEvent(event string, ctx *flag.ParseContext) {
+	if event != flag.EvtResolveCmd || x.pluginAt >= 0 {
+		return
+	}
+	stack := ctx.CmdStack()
+	cmdPath := make([]*flag.Command, len(stack))
+	for i, pcmd := range stack {
+		cmdPath[i] = pcmd.Cmd
+	}
+	if fn := x.Find(cmdPath, ctx.Name); fn != "" {
+		x.plugin = fn
+		x.pluginAt = len(stack) - 1
+		ctx.Ignore = true
+		ctx.ParseEnd()
+	}
+}
+
+// RegisterExt implements ExtRegistrar
+func (x *PluginExt) RegisterExt(parser *flag.Parser) {
+	parser.AddParseExt(flag.EvtResolveCmd, x)
+	parser.AddExecExt(x)
+	x.plugin = ""
+	x.pluginAt = -1
+}
+
+// ExecuteCmd implements execution extension
+func (x *PluginExt) ExecuteCmd(ctx *flag.ExecContext) {
+	if x.pluginAt < 0 || ctx.HasErrors() {
+		return
+	}
+	cmd := exec.Command(x.plugin, ctx.Result.UnparsedArgs...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), x.Envs(ctx.Result.CmdStack[:x.pluginAt+1])...)
+	err := cmd.Run()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+		err = &ExitError{Plugin: x.plugin, Code: code}
+	}
+	if x.Exit && (err == nil || code != 0) {
+		os.Exit(code)
+	}
+	ctx.Done(err)
+}
+
+// Find looks for the plugin of a subcommand under the last command in
+// cmdPath, and returns the path of executable or empty if not found
+func (x *PluginExt) Find(cmdPath []*flag.Command, name string) string {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return ""
+	}
+	base := x.prefix(cmdPath) + name
+	if len(x.Dirs) == 0 {
+		fn, _ := exec.LookPath(base)
+		return fn
+	}
+	for _, dir := range x.Dirs {
+		fn := filepath.Join(dir, base)
+		if executable(fn) {
+			return fn
+		}
+	}
+	return ""
+}
+
+// Envs formats the options parsed in the stack as environment variables
+func (x *PluginExt) Envs(stack []*flag.ParsedCmd) []string {
+	prefix := x.EnvPrefix
+	if prefix == "" && len(stack) > 0 {
+		prefix = envName(x.program(stack[0].Cmd)) + "_"
+	}
+	var envs []string
+	for _, pcmd := range stack {
+		for _, opt := range pcmd.Cmd.Options {
+			if val, exists := pcmd.Vars[opt.Name]; exists && val != nil {
+				envs = append(envs, prefix+envName(opt.Name)+"="+envVal(val))
+			}
+		}
+	}
+	return envs
+}
+
+// HelpCommands implements help.CommandsSource
+func (x *PluginExt) HelpCommands(cmdPath []*flag.Command) (string, []*flag.Command) {
+	prefix := x.prefix(cmdPath)
+	dirs := x.Dirs
+	if len(dirs) == 0 {
+		dirs = filepath.SplitList(os.Getenv("PATH"))
+	}
+	cmd := cmdPath[len(cmdPath)-1]
+	found := make(map[string]bool)
+	var cmds []*flag.Command
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name := f.Name()
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) || found[name] {
+				continue
+			}
+			name = name[len(prefix):]
+			if cmd.FindCommand(strings.SplitN(name, "-", 2)[0]) != nil {
+				// plugin of a nested subcommand
+				continue
+			}
+			if fn := filepath.Join(dir, f.Name()); executable(fn) {
+				found[name] = true
+				cmds = append(cmds, &flag.Command{Name: name, Desc: "plugin " + fn})
+			}
+		}
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return "", cmds
+}
+
+func (x *PluginExt) program(root *flag.Command) string {
+	if x.Program != "" {
+		return x.Program
+	}
+	return root.Name
+}
+
+// prefix builds PROGRAM-CMD- for the plugins under the last command
+func (x *PluginExt) prefix(cmdPath []*flag.Command) string {
+	prefix := x.program(cmdPath[0]) + "-"
+	for _, cmd := range cmdPath[1:] {
+		prefix += cmd.Name + "-"
+	}
+	return prefix
+}
+
+func executable(fn string) bool {
+	info, err := os.Stat(fn)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+func envName(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// envVal formats a value like the ones accepted by flag.Option.ParseEnvVal
+func envVal(val interface{}) string {
+	switch v := val.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprintf("%v", item)
+		}
+		return strings.Join(items, flag.EnvListSeparator)
+	case map[string]interface{}:
+		items := make([]string, 0, len(v))
+		for k, item := range v {
+			items = append(items, fmt.Sprintf("%s=%v", k, item))
+		}
+		sort.Strings(items)
+		return strings.Join(items, flag.EnvListSeparator)
+	}
+	return fmt.Sprintf("%v", val)
+}
@@ -0,0 +1,137 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/codingbrain/clix.go/exts/help"
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCmdDef = `---
+cli:
+    name: test
+    options:
+        - name: log-level
+          default: info
+        - name: tags
+          list: true
+    commands:
+        - name: remote
+          options:
+              - name: dry-run
+                type: boolean
+          commands:
+              - name: add
+`
+
+const testScript = `#!/bin/sh
+echo "$@" > "$0.out"
+echo "$TEST_LOG_LEVEL $TEST_TAGS $TEST_DRY_RUN" >> "$0.out"
+exit 3
+`
+
+func setupPlugins(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported")
+	}
+	dir, err := ioutil.TempDir("", "clix-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"test-hello", "test-remote-ls", "other-x"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(testScript), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "test-noexec"), []byte(testScript), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func loadCli(t *testing.T) *flag.CliDef {
+	cli, err := flag.DecodeCliDefString(testCmdDef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cli
+}
+
+func readOutput(t *testing.T, fn string) string {
+	data, err := ioutil.ReadFile(fn + ".out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunPlugin(t *testing.T) {
+	a := assert.New(t)
+	dir := setupPlugins(t)
+	defer os.RemoveAll(dir)
+
+	cli := loadCli(t)
+	cli.Use(NewExt().SearchDirs(dir).NoExit())
+	r := cli.ParseArgs("test", "--tags=a", "--tags=b", "hello", "--help", "x")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 1) {
+		a.Equal([]string{"--help", "x"}, r.UnparsedArgs)
+	}
+	err := r.Exec()
+	if a.Error(err) {
+		exitErr, ok := err.(*ExitError)
+		if a.True(ok) {
+			a.Equal(3, exitErr.Code)
+			a.Equal(filepath.Join(dir, "test-hello"), exitErr.Plugin)
+		}
+	}
+	a.Equal("--help x\ninfo a,b \n", readOutput(t, filepath.Join(dir, "test-hello")))
+
+	r = cli.ParseArgs("test", "--log-level=debug", "remote", "--dry-run", "ls")
+	a.Error(r.Exec())
+	a.Equal("\ndebug  true\n", readOutput(t, filepath.Join(dir, "test-remote-ls")))
+
+	for _, name := range []string{"noexec", "x", "ls"} {
+		r = cli.ParseArgs("test", name)
+		a.True(r.MissingCmd)
+	}
+}
+
+func TestFindPlugin(t *testing.T) {
+	a := assert.New(t)
+	dir := setupPlugins(t)
+	defer os.RemoveAll(dir)
+
+	cli := loadCli(t)
+	x := NewExt().ProgramName("other")
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	a.Equal(filepath.Join(dir, "other-x"), x.Find([]*flag.Command{cli.Cli}, "x"))
+	a.Empty(x.Find([]*flag.Command{cli.Cli}, "hello"))
+	a.Empty(x.Find([]*flag.Command{cli.Cli}, "../test-hello"))
+}
+
+func TestHelpPlugins(t *testing.T) {
+	a := assert.New(t)
+	dir := setupPlugins(t)
+	defer os.RemoveAll(dir)
+
+	cli := loadCli(t)
+	x := NewExt().SearchDirs(dir)
+	var _ help.CommandsSource = x
+	title, cmds := x.HelpCommands([]*flag.Command{cli.Cli})
+	a.Empty(title)
+	if a.Len(cmds, 1) {
+		a.Equal("hello", cmds[0].Name)
+	}
+	remote := cli.Cli.FindCommand("remote")
+	_, cmds = x.HelpCommands([]*flag.Command{cli.Cli, remote})
+	if a.Len(cmds, 1) {
+		a.Equal("ls", cmds[0].Name)
+	}
+}
@@ -4,12 +4,21 @@ package flag
 type CliDef struct {
 	Cli *Command `yaml:"cli,omitempty"`
 
+	// Definitions are the fragments referenced by $ref in Cli
+	Definitions *Fragments `yaml:"definitions,omitempty"`
+	// Include lists the files providing more Definitions, relative to
+	// the including file, they are merged when decoded by DefDecoder
+	Include []string `yaml:"include,omitempty"`
+
 	exts []ExtRegistrar
 }
 
-// Normalize normalizes the parsed cli definition
+// Normalize resolves the references and normalizes the parsed cli definition
 func (d *CliDef) Normalize() error {
 	if d.Cli != nil {
+		if err := d.ResolveRefs(); err != nil {
+			return err
+		}
 		return d.Cli.Normalize()
 	}
 	return nil
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -19,16 +20,63 @@ type Option struct {
 	Required bool                   `yaml:"required,omitempty"`
 	Default  interface{}            `yaml:"default,omitempty"`
 	List     bool                   `yaml:"list,omitempty"`
+	Env      string                 `yaml:"env,omitempty"`
 	Tags     map[string]interface{} `yaml:"tags,omitempty"`
 
+	// Ref references a definition of option (or option set) in
+	// CliDef.Definitions, the fields set along with it override the
+	// referenced ones, and it's resolved by CliDef.Normalize
+	Ref string `yaml:"$ref,omitempty"`
+
+	// DefaultDesc describes the default value in help instead of the value
+	DefaultDesc string `yaml:"default-desc,omitempty"`
+	// DefaultFunc computes the default value, which overrides Default
+	DefaultFunc DefaultFunc `yaml:"-"`
+
+	// Local options are only accepted by the command defining them,
+	// otherwise the options are persistent and inherited by subcommands
+	Local bool `yaml:"local,omitempty"`
+	// Hidden options are not displayed in help
+	Hidden bool `yaml:"hidden,omitempty"`
+	// Deprecated is the message explaining why the option is deprecated
+	Deprecated string `yaml:"deprecated,omitempty"`
+	// ReplacedBy names the option replacing this deprecated one
+	ReplacedBy string `yaml:"replaced-by,omitempty"`
+	// AllowDashValue accepts a value starting with "-" after the option,
+	// e.g. --pattern -foo, instead of treating it as another option
+	AllowDashValue bool `yaml:"allow-dash-value,omitempty"`
+	// NestedKeys makes a dotted KEY of dict entry KEY=VALUE build nested
+	// dicts, e.g. a.b=1 gives {a: {b: 1}}, otherwise the key is kept as is
+	NestedKeys bool `yaml:"nested-keys,omitempty"`
+
+	// Choices lists all allowed values
+	Choices []interface{} `yaml:"choices,omitempty"`
+	// Min and Max define the range of a number, inclusive
+	Min interface{} `yaml:"min,omitempty"`
+	Max interface{} `yaml:"max,omitempty"`
+	// Pattern is the regular expression a string value must match
+	Pattern string `yaml:"pattern,omitempty"`
+	// MinCount and MaxCount limit the number of values of a list argument,
+	// MaxCount is unlimited if 0
+	MinCount int `yaml:"min-count,omitempty"`
+	MaxCount int `yaml:"max-count,omitempty"`
+
 	IsArg     bool         `yaml:"-"`
+	IsCount   bool         `yaml:"-"`
 	Position  int          `yaml:"-"`
 	SubType   string       `yaml:"-"`
 	ValueKind reflect.Kind `yaml:"-"`
+
+	vtype    *valueType
+	item     *Option         // parses values of dict by SubType
+	refKeys  map[string]bool // the keys decoded along with Ref
+	min, max *float64
+	pattern  *regexp.Regexp
 }
 
 type Command struct {
-	Name      string                 `yaml:"name"`
+	// Name is required unless Ref is set, which is checked by Normalize
+	Name      string                 `yaml:"name,omitempty"`
 	Alias     []string               `yaml:"alias,omitempty"`
 	Desc      string                 `yaml:"description,omitempty"`
 	Example   string                 `yaml:"example,omitempty"`
@@ -37,19 +85,68 @@ type Command struct {
 	Commands  []*Command             `yaml:"commands,omitempty"`
 	Tags      map[string]interface{} `yaml:"tags,omitempty"`
 
+	// Ref references a definition of command in CliDef.Definitions,
+	// the fields set along with it override the referenced ones
+	Ref     string          `yaml:"$ref,omitempty"`
+	refKeys map[string]bool // the keys decoded along with Ref
+
+	// Exclusive lists groups of options which can't be used together
+	Exclusive [][]string `yaml:"exclusive,omitempty"`
+	// Requires maps an option to the options which must be used with it
+	Requires map[string][]string `yaml:"requires,omitempty"`
+	// AtLeastOne lists groups of options where at least one must be used
+	AtLeastOne [][]string `yaml:"at-least-one,omitempty"`
+
+	// Hidden commands are not displayed in help
+	Hidden bool `yaml:"hidden,omitempty"`
+	// Deprecated is the message explaining why the command is deprecated
+	Deprecated string `yaml:"deprecated,omitempty"`
+	// ReplacedBy names the sibling command replacing this deprecated one
+	ReplacedBy string `yaml:"replaced-by,omitempty"`
+	// StopAtArgs stops parsing once the declared arguments are filled,
+	// and the remaining args are passed through as is, like after "--"
+	StopAtArgs bool `yaml:"stop-at-args,omitempty"`
+
 	OptMap  map[string]*Option     `yaml:"-"`
 	ArgMap  map[string]*Option     `yaml:"-"`
 	CmdMap  map[string]*Command    `yaml:"-"`
 	DefVars map[string]interface{} `yaml:"-"`
+	// Warnings are the suspicious definitions found by Normalize in
+	// this command and all subcommands, which don't prevent parsing
+	Warnings []string `yaml:"-"`
 }
 
+// EnvListSeparator separates list items and dict entries
+// in the value of an environment variable
+var EnvListSeparator = ","
+
 type CmdDefError struct {
 	Command string
 	Message string
+	// File, Line and Column locate the definition when decoded from
+	// YAML, Line and Column start from 1 and are 0 if unknown
+	File   string
+	Line   int
+	Column int
 }
 
 func (e *CmdDefError) Error() string {
-	return "Command Definition Error: " + e.Command + ": " + e.Message
+	return "Command Definition Error: " + e.Location() + e.Command + ": " + e.Message
+}
+
+// Location returns the location like file:line:col: or empty if unknown
+func (e *CmdDefError) Location() string {
+	loc := e.File
+	if e.Line > 0 {
+		if loc != "" {
+			loc += ":"
+		}
+		loc += strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column)
+	}
+	if loc != "" {
+		loc += ": "
+	}
+	return loc
 }
 
 func tagString(tags map[string]interface{}, name string) (string, bool) {
@@ -84,7 +181,28 @@ func (opt *Option) TagBool(name string) (bool, bool) {
 	return tagBool(opt.Tags, name)
 }
 
+// ValueError is the error when a value violates the validation rules
+type ValueError struct {
+	Reason string
+}
+
+func (e *ValueError) Error() string {
+	return e.Reason
+}
+
+// ParseStrVal parses a single value from string and validates it
 func (opt *Option) ParseStrVal(val string) (interface{}, error) {
+	parsedVal, err := opt.parseStrVal(val)
+	if err == nil {
+		err = opt.Validate(parsedVal)
+	}
+	return parsedVal, err
+}
+
+func (opt *Option) parseStrVal(val string) (interface{}, error) {
+	if opt.vtype != nil {
+		return opt.vtype.parseStr(val)
+	}
 	switch opt.ValueKind {
 	case reflect.String:
 		return val, nil
@@ -95,30 +213,108 @@ func (opt *Option) ParseStrVal(val string) (interface{}, error) {
 	case reflect.Float64:
 		return strconv.ParseFloat(val, 64)
 	case reflect.Map:
-		if pos := strings.IndexByte(val, '='); pos == 0 {
-			return nil, errors.New(errMsgNameEmpty)
-		} else if pos > 0 {
-			return map[string]interface{}{val[0:pos]: val[pos+1:]}, nil
+		return opt.parseDictEntry(val)
+	}
+	panic(errMsgInvalidType + opt.ValueKind.String())
+}
+
+// ParseEnvVal parses the value from an environment variable.
+// For list and dict, the items are separated by EnvListSeparator,
+// and each item of dict is in the form of KEY=VALUE.
+func (opt *Option) ParseEnvVal(val string) (interface{}, error) {
+	if !opt.List && opt.ValueKind != reflect.Map {
+		return opt.ParseStrVal(val)
+	}
+	list := []interface{}{}
+	dict := make(map[string]interface{})
+	for _, item := range strings.Split(val, EnvListSeparator) {
+		if item == "" {
+			continue
+		}
+		parsedVal, err := opt.ParseStrVal(item)
+		if err != nil {
+			return nil, err
+		}
+		if opt.ValueKind == reflect.Map {
+			mergeDict(dict, parsedVal.(map[string]interface{}))
 		} else {
-			return map[string]interface{}{val: true}, nil
+			list = append(list, parsedVal)
 		}
 	}
-	panic(errMsgInvalidType + opt.ValueKind.String())
+	if opt.ValueKind == reflect.Map {
+		return dict, nil
+	}
+	return list, nil
+}
+
+// Validate checks a single parsed value (not a list) against Choices,
+// Min, Max and Pattern, and returns *ValueError if any is violated
+func (opt *Option) Validate(val interface{}) error {
+	if len(opt.Choices) > 0 {
+		found := false
+		for _, c := range opt.Choices {
+			if c == val {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &ValueError{"must be one of " + strings.Join(opt.ChoiceStrings(), ", ")}
+		}
+	}
+	if opt.min != nil || opt.max != nil {
+		if num, ok := numVal(val); ok {
+			if opt.min != nil && num < *opt.min {
+				return &ValueError{fmt.Sprintf("must be at least %v", opt.Min)}
+			}
+			if opt.max != nil && num > *opt.max {
+				return &ValueError{fmt.Sprintf("must be at most %v", opt.Max)}
+			}
+		}
+	}
+	if opt.pattern != nil {
+		if str, ok := val.(string); ok && !opt.pattern.MatchString(str) {
+			return &ValueError{"must match " + opt.Pattern}
+		}
+	}
+	return nil
+}
+
+// ChoiceStrings formats Choices as strings
+func (opt *Option) ChoiceStrings() []string {
+	strs := make([]string, len(opt.Choices))
+	for i, c := range opt.Choices {
+		strs[i] = fmt.Sprintf("%v", c)
+	}
+	return strs
+}
+
+// IsDeprecated tells whether the option is deprecated
+func (opt *Option) IsDeprecated() bool {
+	return opt.Deprecated != "" || opt.ReplacedBy != ""
 }
 
 func (opt *Option) DefaultAsString() string {
-	if opt.Default == nil || opt.List || opt.ValueKind == reflect.Map {
+	if opt.List || opt.ValueKind == reflect.Map {
+		return ""
+	}
+	if opt.IsComputedDefault() {
+		if val, err := opt.ResolveDefault(); err == nil && val != nil {
+			return fmt.Sprintf("%v", val)
+		}
+	}
+	if opt.Default == nil {
 		return ""
 	}
 	return fmt.Sprintf("%v", opt.Default)
 }
 
 func (opt *Option) ExpectValue() bool {
-	return opt.IsArg || opt.ValueKind != reflect.Bool
+	return opt.IsArg || opt.ValueKind != reflect.Bool && !opt.IsCount
 }
 
 func (opt *Option) defError(cmdPath, msg string) *CmdDefError {
-	return &CmdDefError{cmdPath + "[" + opt.Name + "]", msg}
+	return &CmdDefError{Command: cmdPath + "[" + opt.Name + "]", Message: msg}
 }
 
 func (opt *Option) normalizeType(cmdPath string) error {
@@ -126,6 +322,7 @@ func (opt *Option) normalizeType(cmdPath string) error {
 		opt.SubType = opt.Type[pos+1:]
 		opt.Type = opt.Type[0:pos]
 	}
+	opt.IsCount = false
 	switch opt.Type {
 	case "string", "str", "text", "":
 		opt.ValueKind = reflect.String
@@ -135,12 +332,26 @@ func (opt *Option) normalizeType(cmdPath string) error {
 		opt.ValueKind = reflect.Float64
 	case "boolean", "bool":
 		opt.ValueKind = reflect.Bool
+	case "count":
+		// increased by one every time used without a value, e.g. -vvv gives 3
+		opt.ValueKind = reflect.Int64
+		opt.IsCount = true
+		opt.List = false
 	case "map", "dict":
 		opt.ValueKind = reflect.Map
 		opt.List = false
+		opt.vtype = nil
+		return opt.normalizeDict(cmdPath)
 	default:
-		return opt.defError(cmdPath, errMsgInvalidType+opt.Type)
+		vtype, ok := valueTypes[opt.Type]
+		if !ok {
+			return opt.defError(cmdPath, errMsgInvalidType+opt.Type)
+		}
+		opt.ValueKind = reflect.Interface
+		opt.vtype = vtype
+		return nil
 	}
+	opt.vtype = nil
 	return nil
 }
 
@@ -158,6 +369,73 @@ func (opt *Option) normalizeAsOption(cmdPath string) error {
 	if err := opt.normalizeType(cmdPath); err != nil {
 		return err
 	}
+	if err := opt.normalizeRules(cmdPath); err != nil {
+		return err
+	}
+	if opt.MinCount != 0 || opt.MaxCount != 0 {
+		// only applicable to list arguments
+		return opt.defError(cmdPath, errMsgRuleNotApplicable+"min-count/max-count")
+	}
+	return opt.normalizeEnv(cmdPath)
+}
+
+func (opt *Option) normalizeRules(cmdPath string) error {
+	if opt.NestedKeys && opt.ValueKind != reflect.Map {
+		return opt.defError(cmdPath, errMsgRuleNotApplicable+"nested-keys")
+	}
+	if len(opt.Choices) > 0 {
+		if opt.ValueKind == reflect.Bool || opt.ValueKind == reflect.Map || opt.vtype != nil {
+			return opt.defError(cmdPath, errMsgRuleNotApplicable+"choices")
+		}
+		for i, c := range opt.Choices {
+			val, err := opt.convert(c)
+			if err != nil {
+				return opt.defError(cmdPath, errMsgInvalidRule+"choices: "+err.Error())
+			}
+			opt.Choices[i] = val
+		}
+	}
+	opt.min, opt.max = nil, nil
+	for _, r := range []struct {
+		name string
+		val  interface{}
+		dest **float64
+	}{{"min", opt.Min, &opt.min}, {"max", opt.Max, &opt.max}} {
+		if r.val == nil {
+			continue
+		}
+		if opt.ValueKind != reflect.Int64 && opt.ValueKind != reflect.Float64 &&
+			(opt.vtype == nil || reflect.TypeOf(opt.vtype.zero).Kind() != reflect.Int64) {
+			return opt.defError(cmdPath, errMsgRuleNotApplicable+r.name)
+		}
+		val, err := opt.convert(r.val)
+		if err != nil {
+			return opt.defError(cmdPath, errMsgInvalidRule+r.name+": "+err.Error())
+		}
+		num, _ := numVal(val)
+		*r.dest = &num
+	}
+	if opt.min != nil && opt.max != nil && *opt.min > *opt.max {
+		return opt.defError(cmdPath, errMsgInvalidRule+"min is greater than max")
+	}
+	opt.pattern = nil
+	if opt.Pattern != "" {
+		if opt.ValueKind != reflect.String {
+			return opt.defError(cmdPath, errMsgRuleNotApplicable+"pattern")
+		}
+		re, err := regexp.Compile(opt.Pattern)
+		if err != nil {
+			return opt.defError(cmdPath, errMsgInvalidRule+"pattern: "+err.Error())
+		}
+		opt.pattern = re
+	}
+	return nil
+}
+
+func (opt *Option) normalizeEnv(cmdPath string) error {
+	if strings.ContainsAny(opt.Env, "= \t") {
+		return opt.defError(cmdPath, errMsgInvalidEnv+opt.Env)
+	}
 	return nil
 }
 
@@ -165,12 +443,34 @@ func (opt *Option) normalizeAsArgument(cmdPath string, position int) error {
 	if err := opt.normalizeType(cmdPath); err != nil {
 		return err
 	}
+	if err := opt.normalizeRules(cmdPath); err != nil {
+		return err
+	}
+	if err := opt.normalizeEnv(cmdPath); err != nil {
+		return err
+	}
+	if err := opt.normalizeCounts(cmdPath); err != nil {
+		return err
+	}
 	opt.IsArg = true
-	opt.List = false            // for arguments, list should always be false
 	opt.Position = position + 1 // position starts from 1
 	return nil
 }
 
+func (opt *Option) normalizeCounts(cmdPath string) error {
+	if opt.MinCount == 0 && opt.MaxCount == 0 {
+		return nil
+	}
+	if !opt.List {
+		return opt.defError(cmdPath, errMsgRuleNotApplicable+"min-count/max-count")
+	}
+	if opt.MinCount < 0 || opt.MaxCount < 0 ||
+		opt.MaxCount > 0 && opt.MinCount > opt.MaxCount {
+		return opt.defError(cmdPath, errMsgInvalidRule+"min-count/max-count")
+	}
+	return nil
+}
+
 func scalarKind(kind reflect.Kind) bool {
 	switch kind {
 	case reflect.Bool,
@@ -210,10 +510,20 @@ func floatVal(val interface{}) (float64, bool) {
 	return 0, false
 }
 
+// numVal converts any number to float64
+func numVal(val interface{}) (float64, bool) {
+	if f, ok := floatVal(val); ok {
+		return f, true
+	} else if i, ok := intVal(val); ok {
+		return float64(i), true
+	} else if u, ok := uintVal(val); ok {
+		return float64(u), true
+	}
+	return 0, false
+}
+
 func parseNotSlice(kind reflect.Kind, val interface{}) (interface{}, error) {
-	// same scalar type can be passed through, map should be handled specially
-	// in some cases, the map type is like map[interface{}]interface{}
-	// what is wanted here is map[string]interface{}
+	// same scalar type can be passed through, map is handled by convertDict
 	if k := reflect.ValueOf(val).Kind(); k == kind && k != reflect.Map {
 		return val, nil
 	}
@@ -248,38 +558,19 @@ func parseNotSlice(kind reflect.Kind, val interface{}) (interface{}, error) {
 		} else if uintVal, ok := uintVal(val); ok {
 			return float64(int64(uintVal)), nil
 		}
-	case reflect.Map:
-		if str, ok := val.(string); ok {
-			if pos := strings.IndexByte(str, '='); pos == 0 {
-				return nil, errors.New(errMsgNameEmpty)
-			} else if pos > 0 {
-				return map[string]interface{}{str[0:pos]: str[pos+1:]}, nil
-			} else {
-				return map[string]interface{}{str: true}, nil
-			}
-		} else if sv := reflect.ValueOf(val); sv.Kind() == reflect.Map {
-			des := make(map[string]interface{})
-			for _, kv := range sv.MapKeys() {
-				vv := sv.MapIndex(kv)
-				if !kv.CanInterface() || !vv.CanInterface() {
-					return nil, errors.New(errMsgInvalidType + sv.Kind().String())
-				}
-				key := fmt.Sprintf("%v", kv.Interface())
-				des[key] = vv.Interface()
-			}
-			return des, nil
-		}
 	}
 	return nil, errors.New(errMsgInvalidType + reflect.ValueOf(val).Kind().String())
 }
 
-func (opt *Option) parseDefaultVal() (interface{}, error) {
+// ParseVal converts a value, e.g. decoded from YAML/JSON, to the type of option
+func (opt *Option) ParseVal(val interface{}) (interface{}, error) {
 	if !opt.List {
-		return parseNotSlice(opt.ValueKind, opt.Default)
+		return opt.parseItem(val)
 	}
-	rv := reflect.ValueOf(opt.Default)
-	if kind := rv.Kind(); scalarKind(kind) {
-		parsedVal, err := parseNotSlice(opt.ValueKind, opt.Default)
+	rv := reflect.ValueOf(val)
+	typed := opt.vtype != nil && val != nil && rv.Type() == reflect.TypeOf(opt.vtype.zero)
+	if kind := rv.Kind(); scalarKind(kind) || typed {
+		parsedVal, err := opt.parseItem(val)
 		if err != nil {
 			return nil, err
 		}
@@ -291,7 +582,7 @@ func (opt *Option) parseDefaultVal() (interface{}, error) {
 			if !src.CanInterface() {
 				return nil, errors.New(errMsgInvalidType + src.Kind().String())
 			}
-			parsedVal, err := parseNotSlice(opt.ValueKind, src.Interface())
+			parsedVal, err := opt.parseItem(src.Interface())
 			if err != nil {
 				return nil, err
 			}
@@ -303,18 +594,40 @@ func (opt *Option) parseDefaultVal() (interface{}, error) {
 	}
 }
 
+// convert converts a single value to the type of option without validation
+func (opt *Option) convert(val interface{}) (interface{}, error) {
+	if opt.vtype != nil {
+		return opt.vtype.convert(val)
+	}
+	if opt.ValueKind == reflect.Map {
+		return opt.convertDict(val)
+	}
+	return parseNotSlice(opt.ValueKind, val)
+}
+
+func (opt *Option) parseItem(val interface{}) (interface{}, error) {
+	parsedVal, err := opt.convert(val)
+	if err == nil {
+		err = opt.Validate(parsedVal)
+	}
+	return parsedVal, err
+}
+
 func (opt *Option) defaultVar(cmdPath string, vars map[string]interface{}) error {
 	var v interface{}
 	if opt.Required {
 		return nil
-	} else if opt.Default != nil {
-		val, err := opt.parseDefaultVal()
+	} else if opt.Default != nil && !needsExpand(opt.Default) {
+		// the default to be expanded is converted when parsing
+		val, err := opt.ParseVal(opt.Default)
 		if err != nil {
 			return opt.defError(cmdPath, "invalid default value: "+err.Error())
 		}
 		v = val
 	} else if opt.List {
 		v = []interface{}{}
+	} else if opt.vtype != nil {
+		v = opt.vtype.zero
 	} else {
 		switch opt.ValueKind {
 		case reflect.String:
@@ -353,6 +666,20 @@ func indexOpt(cmdPath string, optMap, map1 map[string]*Option, opt *Option) erro
 	return nil
 }
 
+// shadowWarnings reports the names of an option also used by the
+// persistent options inherited from parent commands, the option of
+// the command wins when parsing
+func shadowWarnings(cmdPath string, inherited map[string]*Option, opt *Option) []string {
+	var warnings []string
+	for _, name := range append([]string{opt.Name}, opt.Alias...) {
+		if shadowed, exists := inherited[name]; exists {
+			warnings = append(warnings,
+				cmdPath+": "+name+" of "+opt.Name+" shadows inherited option "+shadowed.Name)
+		}
+	}
+	return warnings
+}
+
 func indexCmd(cmdPath string, cmdMap map[string]*Command, cmd *Command) error {
 	names := append([]string{cmd.Name}, cmd.Alias...)
 	for _, name := range names {
@@ -360,7 +687,7 @@ func indexCmd(cmdPath string, cmdMap map[string]*Command, cmd *Command) error {
 			continue
 		}
 		if _, exists := cmdMap[name]; exists {
-			return &CmdDefError{cmdPath + "/" + cmd.Name, errMsgDupName}
+			return &CmdDefError{Command: cmdPath + "/" + cmd.Name, Message: errMsgDupName}
 		}
 		cmdMap[name] = cmd
 	}
@@ -395,7 +722,14 @@ func (cmd *Command) TagBool(name string) (bool, bool) {
 	return tagBool(cmd.Tags, name)
 }
 
-func (cmd *Command) DefaultVars(vars map[string]interface{}) {
+// IsDeprecated tells whether the command is deprecated
+func (cmd *Command) IsDeprecated() bool {
+	return cmd.Deprecated != "" || cmd.ReplacedBy != ""
+}
+
+// DefaultVars fills vars with the default values, and returns the errors
+// of computed default values, see Option.ResolveDefault
+func (cmd *Command) DefaultVars(vars map[string]interface{}) []*VarError {
 	for k, v := range cmd.DefVars {
 		if dict, ok := v.(map[string]interface{}); ok {
 			dest := make(map[string]interface{})
@@ -409,11 +743,14 @@ func (cmd *Command) DefaultVars(vars map[string]interface{}) {
 			vars[k] = v
 		}
 	}
+	return cmd.computeDefaults(vars)
 }
 
-func (cmd *Command) normalizeAsCommand(cmdPath string) error {
+// normalizeAsCommand normalizes the command and subcommands recursively,
+// inherited indexes the persistent options of parent commands
+func (cmd *Command) normalizeAsCommand(cmdPath string, inherited map[string]*Option) error {
 	if cmd.Name == "" {
-		return &CmdDefError{cmdPath, errMsgNameEmpty}
+		return &CmdDefError{Command: cmdPath, Message: errMsgNameEmpty}
 	}
 	if cmdPath != "" {
 		cmdPath += "/"
@@ -423,6 +760,7 @@ func (cmd *Command) normalizeAsCommand(cmdPath string) error {
 	cmd.ArgMap = make(map[string]*Option)
 	cmd.CmdMap = make(map[string]*Command)
 	cmd.DefVars = make(map[string]interface{})
+	cmd.Warnings = nil
 	errs := &merr.AggregatedError{}
 	for _, opt := range cmd.Options {
 		if errs.Add(opt.normalizeAsOption(cmdPath)) {
@@ -431,26 +769,88 @@ func (cmd *Command) normalizeAsCommand(cmdPath string) error {
 		if errs.Add(indexOpt(cmdPath, cmd.OptMap, cmd.ArgMap, opt)) {
 			continue
 		}
+		cmd.Warnings = append(cmd.Warnings, shadowWarnings(cmdPath, inherited, opt)...)
 		errs.Add(opt.defaultVar(cmdPath, cmd.DefVars))
 	}
 	for i, arg := range cmd.Arguments {
 		if errs.Add(arg.normalizeAsArgument(cmdPath, i)) {
 			continue
 		}
+		if arg.List && i < len(cmd.Arguments)-1 {
+			errs.Add(arg.defError(cmdPath, errMsgListArgNotLast))
+			continue
+		}
 		if errs.Add(indexOpt(cmdPath, cmd.ArgMap, cmd.OptMap, arg)) {
 			continue
 		}
 		errs.Add(arg.defaultVar(cmdPath, cmd.DefVars))
 	}
+	for _, group := range cmd.Exclusive {
+		errs.Add(cmd.checkConstraint(cmdPath, group, 2))
+	}
+	for name, opts := range cmd.Requires {
+		errs.Add(cmd.checkConstraint(cmdPath, append([]string{name}, opts...), 2))
+	}
+	for _, group := range cmd.AtLeastOne {
+		errs.Add(cmd.checkConstraint(cmdPath, group, 1))
+	}
+	subInherited := make(map[string]*Option)
+	for name, opt := range inherited {
+		subInherited[name] = opt
+	}
+	for name, opt := range cmd.OptMap {
+		if !opt.Local {
+			subInherited[name] = opt
+		}
+	}
 	for _, sub := range cmd.Commands {
-		if errs.Add(sub.normalizeAsCommand(cmdPath)) {
+		if errs.Add(sub.normalizeAsCommand(cmdPath, subInherited)) {
 			continue
 		}
 		errs.Add(indexCmd(cmdPath, cmd.CmdMap, sub))
 	}
+	for _, opt := range cmd.Options {
+		if opt.ReplacedBy == "" {
+			continue
+		}
+		if repl := cmd.OptMap[opt.ReplacedBy]; repl == opt || repl == nil && inherited[opt.ReplacedBy] == nil {
+			errs.Add(opt.defError(cmdPath, errMsgReplacementNoOpt+opt.ReplacedBy))
+		}
+	}
+	for _, sub := range cmd.Commands {
+		if repl := cmd.CmdMap[sub.ReplacedBy]; sub.ReplacedBy != "" && (repl == nil || repl == sub) {
+			errs.Add(&CmdDefError{Command: cmdPath + "/" + sub.Name, Message: errMsgReplacementNoCmd + sub.ReplacedBy})
+		}
+	}
+	optNames := make(map[string]string)
+	for name, opt := range cmd.OptMap {
+		optNames[name] = opt.Name
+	}
+	cmdNames := make(map[string]string)
+	for name, sub := range cmd.CmdMap {
+		cmdNames[name] = sub.Name
+	}
+	cmd.Warnings = append(cmd.Warnings, prefixWarnings(cmdPath, optNames)...)
+	cmd.Warnings = append(cmd.Warnings, prefixWarnings(cmdPath, cmdNames)...)
+	for _, sub := range cmd.Commands {
+		cmd.Warnings = append(cmd.Warnings, sub.Warnings...)
+	}
 	return errs.Aggregate()
 }
 
+// checkConstraint validates the options referred in a constraint
+func (cmd *Command) checkConstraint(cmdPath string, names []string, minCount int) error {
+	if len(names) < minCount {
+		return &CmdDefError{Command: cmdPath, Message: fmt.Sprintf(errMsgConstraintTooFew, minCount)}
+	}
+	for _, name := range names {
+		if cmd.OptMap[name] == nil {
+			return &CmdDefError{Command: cmdPath, Message: errMsgConstraintNoOpt + name}
+		}
+	}
+	return nil
+}
+
 func (cmd *Command) Normalize() error {
-	return cmd.normalizeAsCommand("")
+	return cmd.normalizeAsCommand("", nil)
 }
@@ -82,6 +82,92 @@ func TestNames(t *testing.T) {
 	a.Error(err)
 }
 
+func TestConstraintDefs(t *testing.T) {
+	a := assert.New(t)
+	_, err := DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: a
+              type: string
+        exclusive:
+            - [a, b]
+    `)
+	a.Error(err)
+
+	_, err = DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: a
+              type: string
+        exclusive:
+            - [a]
+    `)
+	a.Error(err)
+
+	_, err = DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: a
+              type: string
+        arguments:
+            - name: b
+        requires:
+            a: [b]
+    `)
+	a.Error(err)
+
+	_, err = DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: aa
+              alias: [a]
+              type: string
+            - name: b
+              type: string
+        exclusive:
+            - [a, b]
+        requires:
+            b: [aa]
+        at-least-one:
+            - [b]
+    `)
+	a.NoError(err)
+}
+
+func TestValueRuleDefs(t *testing.T) {
+	a := assert.New(t)
+	for _, opt := range []string{
+		"{name: a, type: bool, choices: [true]}",
+		"{name: a, type: int, choices: [x]}",
+		"{name: a, type: string, min: 1}",
+		"{name: a, type: int, min: x}",
+		"{name: a, type: int, min: 2, max: 1}",
+		"{name: a, type: int, pattern: 'a'}",
+		"{name: a, type: string, pattern: '('}",
+		"{name: a, type: string, choices: [x, y], default: z}",
+		"{name: a, type: int, list: true, max: 3, default: [1, 5]}",
+	} {
+		_, err := DecodeCmdsString("{name: cmd, options: [" + opt + "]}")
+		a.Error(err, opt)
+	}
+	cmds, err := DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: a
+              type: number
+              choices: [1, 2.5]
+              min: 1
+              max: 3
+              default: 2.5
+    `)
+	if a.NoError(err) {
+		opt := cmds.FindOption("a")
+		a.Equal([]interface{}{float64(1), float64(2.5)}, opt.Choices)
+		a.NoError(opt.Validate(float64(1)))
+		a.Error(opt.Validate(float64(2)))
+	}
+}
+
 func TestTags(t *testing.T) {
 	a := assert.New(t)
 	cmd, err := DecodeCmdsString(`---
@@ -144,6 +230,8 @@ func TestNotList(t *testing.T) {
         arguments:
           - name: arg
             type: string
+          - name: args
+            type: string
             list: true
     `)
 	if a.NoError(err) && a.NotNil(cmd) {
@@ -153,12 +241,32 @@ func TestNotList(t *testing.T) {
 		a.False(arg.List)
 		a.Equal(1, arg.Position)
 
+		arg = cmd.FindArgument("args")
+		a.NotNil(arg)
+		a.True(arg.List)
+		a.Equal(2, arg.Position)
+
 		arg = cmd.FindOption("l1")
 		a.NotNil(arg)
 		a.False(arg.List)
 	}
 }
 
+func TestListArgDefs(t *testing.T) {
+	a := assert.New(t)
+	for _, args := range []string{
+		"[{name: a, list: true}, {name: b}]",
+		"[{name: a, min-count: 1}]",
+		"[{name: a, list: true, min-count: -1}]",
+		"[{name: a, list: true, min-count: 3, max-count: 2}]",
+	} {
+		_, err := DecodeCmdsString("{name: cmd, arguments: " + args + "}")
+		a.Error(err, args)
+	}
+	_, err := DecodeCmdsString("{name: cmd, options: [{name: a, list: true, max-count: 1}]}")
+	a.Error(err)
+}
+
 func TestDefValuesMap(t *testing.T) {
 	a := assert.New(t)
 	cmd, err := DecodeCmdsString(`---
@@ -288,3 +396,148 @@ func TestEmptyList(t *testing.T) {
 		a.Equal([]interface{}{}, cmd.DefVars["l1"])
 	}
 }
+
+func TestPrefixWarnings(t *testing.T) {
+	a := assert.New(t)
+	cmd, err := DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: verbose
+              alias: [v]
+            - name: level
+              alias: [verb]
+        commands:
+            - name: install
+            - name: sub
+              alias: [inst]
+              options:
+                  - name: force
+                  - name: fast
+                    alias: [fo]
+    `)
+	if a.NoError(err) {
+		a.Equal([]string{
+			"cmd: alias verb of level is a prefix of verbose",
+			"cmd: alias inst of sub is a prefix of install",
+			"cmd/sub: alias fo of fast is a prefix of force",
+		}, cmd.Warnings)
+		a.Equal([]string{"cmd/sub: alias fo of fast is a prefix of force"},
+			cmd.FindCommand("sub").Warnings)
+	}
+}
+
+func TestShadowedOptions(t *testing.T) {
+	a := assert.New(t)
+	cmd, err := DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: server
+              alias: [s]
+        commands:
+            - name: sub
+              commands:
+                  - name: subsub
+                    options:
+                        - name: source
+                          alias: [s]
+    `)
+	if a.NoError(err) {
+		a.Equal([]string{"cmd/sub/subsub: s of source shadows inherited option server"},
+			cmd.Warnings)
+		subsub := cmd.FindCommand("sub").FindCommand("subsub")
+		a.Equal("source", subsub.FindOption("s").Name)
+	}
+
+	_, err = DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: force
+              local: true
+        commands:
+            - name: sub
+              options:
+                  - name: force
+    `)
+	a.NoError(err)
+}
+
+func TestReplacementDefs(t *testing.T) {
+	a := assert.New(t)
+	_, err := DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: server
+        commands:
+            - name: sub
+              options:
+                  - name: host
+                    replaced-by: server
+            - name: old
+              replaced-by: sub
+    `)
+	a.NoError(err)
+
+	_, err = DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: host
+              replaced-by: server
+    `)
+	a.Error(err)
+
+	_, err = DecodeCmdsString(`---
+        name: cmd
+        commands:
+            - name: old
+              replaced-by: old
+    `)
+	a.Error(err)
+}
+
+func TestDictSubTypes(t *testing.T) {
+	a := assert.New(t)
+	for _, typ := range []string{"dict/count", "dict/dict", "dict/wrong", "dict/int/int"} {
+		_, err := DecodeCmdsString(`---
+        name: cmd
+        options:
+          - name: opt
+            type: ` + typ)
+		a.Error(err, typ)
+	}
+
+	cmd, err := DecodeCmdsString(`---
+        name: cmd
+        options:
+          - name: ints
+            type: dict/int
+            default:
+              a: "1"
+              b:
+                c: 2
+          - name: nested
+            type: dict/bool
+            nested-keys: true
+            default: a.b
+    `)
+	if a.NoError(err) {
+		a.Equal(map[string]interface{}{
+			"a": int64(1),
+			"b": map[string]interface{}{"c": int64(2)},
+		}, cmd.DefVars["ints"])
+		a.Equal(map[string]interface{}{
+			"a": map[string]interface{}{"b": true},
+		}, cmd.DefVars["nested"])
+		a.Equal("dict", cmd.Options[0].Type)
+		a.Equal("int", cmd.Options[0].SubType)
+	}
+
+	_, err = DecodeCmdsString(`---
+        name: cmd
+        options:
+          - name: ints
+            type: dict/int
+            default:
+              a: x
+    `)
+	a.Error(err)
+}
@@ -0,0 +1,86 @@
+package flag
+
+import "strings"
+
+const (
+	// CompleteNone means nothing can be completed
+	CompleteNone = iota
+	// CompleteCmd means a subcommand is expected
+	CompleteCmd
+	// CompleteOptName means an option name is being typed
+	CompleteOptName
+	// CompleteOptVal means the value of an option is expected
+	CompleteOptVal
+	// CompleteArg means a positional argument is expected
+	CompleteArg
+)
+
+// CompletePoint describes what is expected at the word being completed
+type CompletePoint struct {
+	Kind int
+	// Prefix is the partial word to be completed, for CompleteOptVal
+	// it doesn't include "--name=" in the word
+	Prefix string
+	// Option is the option or argument for CompleteOptVal and CompleteArg,
+	// it can be nil if the argument is not defined
+	Option *Option
+	// OptionAt is the position in CmdStack where Option is defined
+	OptionAt int
+	// CmdStack is the commands parsed before the word
+	CmdStack []*ParsedCmd
+}
+
+// Complete parses the args (including args[0]) except the last one which
+// is the partial word being completed, and determines what is expected.
+// The parser should not be used after this.
+func (p *Parser) Complete(args ...string) *CompletePoint {
+	if len(args) < 2 {
+		return &CompletePoint{Kind: CompleteNone}
+	}
+	word := args[len(args)-1]
+	for _, arg := range args[:len(args)-1] {
+		p.parse(arg)
+	}
+	pt := &CompletePoint{Prefix: word, CmdStack: p.result.CmdStack}
+	state := p.state
+	if state == stateVal && p.optLong && strings.HasPrefix(word, "-") && !p.option.AllowDashValue {
+		// the value of a long option can't start with "-" unless allowed
+		state = stateCmd
+	}
+	switch state {
+	case stateVal:
+		pt.Kind = CompleteOptVal
+		pt.Option, pt.OptionAt = p.option, p.stackPos
+	case stateCmd:
+		if pos := strings.IndexByte(word, '='); strings.HasPrefix(word, "--") && pos > 2 {
+			if opt, at, _ := p.findLongOption(word[2:pos]); opt != nil && opt.ExpectValue() {
+				pt.Kind = CompleteOptVal
+				pt.Option, pt.OptionAt = opt, at
+				pt.Prefix = word[pos+1:]
+			}
+		} else if strings.HasPrefix(word, "-") {
+			pt.Kind = CompleteOptName
+		} else if p.currCmd.hasSubCommands() {
+			pt.Kind = CompleteCmd
+		} else {
+			p.completeArg(pt)
+		}
+	case stateEnd:
+		p.completeArg(pt)
+	}
+	return pt
+}
+
+func (p *Parser) completeArg(pt *CompletePoint) {
+	pt.Kind = CompleteArg
+	pt.OptionAt = len(p.result.CmdStack) - 1
+	pt.Option = p.currCmd.nextArg()
+}
+
+// CurrentCmd returns the innermost command parsed
+func (pt *CompletePoint) CurrentCmd() *ParsedCmd {
+	if l := len(pt.CmdStack); l > 0 {
+		return pt.CmdStack[l-1]
+	}
+	return nil
+}
@@ -1,13 +1,194 @@
 package flag
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 
+	merr "github.com/easeway/langx.go/errors"
 	"gopkg.in/yaml.v2"
 )
 
+// DefDecoder decodes definitions of commands from YAML,
+// and locates the definition errors in the source
+type DefDecoder struct {
+	// Strict rejects the keys not defined by CliDef, Command and Option
+	Strict bool
+	// File is the name of the source reported in errors
+	File string
+}
+
+// NewDefDecoder creates a DefDecoder
+func NewDefDecoder() *DefDecoder {
+	return &DefDecoder{}
+}
+
+// StrictKeys enables/disables rejecting unknown keys
+func (d *DefDecoder) StrictKeys(strict bool) *DefDecoder {
+	d.Strict = strict
+	return d
+}
+
+// FileName sets the name of the source reported in errors
+func (d *DefDecoder) FileName(name string) *DefDecoder {
+	d.File = name
+	return d
+}
+
+// DecodeCmdsBytes decodes the definition of a top-level command
+func (d *DefDecoder) DecodeCmdsBytes(def []byte) (*Command, error) {
+	cmd := &Command{}
+	if decoded, err := d.decode(def, cmd, cmd.Normalize); !decoded {
+		return nil, err
+	} else {
+		return cmd, err
+	}
+}
+
+// DecodeCliDefBytes decodes the definition of CliDef
+func (d *DefDecoder) DecodeCliDefBytes(def []byte) (*CliDef, error) {
+	cliDef := &CliDef{}
+	normalize := func() error {
+		if err := d.includeDefs(cliDef, d.File, nil); err != nil {
+			return err
+		}
+		return cliDef.Normalize()
+	}
+	if decoded, err := d.decode(def, cliDef, normalize); !decoded {
+		return nil, err
+	} else {
+		return cliDef, err
+	}
+}
+
+// DecodeCliDefFile decodes CliDef from a file,
+// the file name is reported in errors if File is not set
+func (d *DefDecoder) DecodeCliDefFile(filename string) (*CliDef, error) {
+	def, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	decoder := *d
+	if decoder.File == "" {
+		decoder.File = filename
+	}
+	return decoder.DecodeCliDefBytes(def)
+}
+
+// decode unmarshals and normalizes the definition, decoded is false
+// if the YAML is malformed, otherwise the errors are definition errors
+func (d *DefDecoder) decode(def []byte, out interface{}, normalize func() error) (decoded bool, err error) {
+	if err = yaml.Unmarshal(def, out); err != nil {
+		if d.File != "" {
+			err = fmt.Errorf("%s: %v", d.File, err)
+		}
+		return
+	}
+	loc := locateDefs(def)
+	errs := &merr.AggregatedError{}
+	if d.Strict {
+		errs.Add(checkDefKeys(def, out, loc))
+	}
+	errs.Add(normalize())
+	err = errs.Aggregate()
+	d.locateErrors(err, loc)
+	return true, err
+}
+
+// locateErrors fills the locations of CmdDefError
+func (d *DefDecoder) locateErrors(err error, loc *defLocator) {
+	switch e := err.(type) {
+	case *merr.AggregatedError:
+		for _, err := range e.Errors {
+			d.locateErrors(err, loc)
+		}
+	case *CmdDefError:
+		if e.File != "" && e.File != d.File {
+			// located in an included file
+			return
+		}
+		e.File = d.File
+		if pos, ok := loc.defs[e.Command]; ok && e.Line == 0 {
+			e.Line, e.Column = pos.line, pos.col
+		}
+	}
+}
+
+// checkDefKeys reports the unknown keys in the definition decoded to out
+func checkDefKeys(def []byte, out interface{}, loc *defLocator) error {
+	var tree interface{}
+	if err := yaml.Unmarshal(def, &tree); err != nil {
+		return err
+	}
+	errs := &merr.AggregatedError{}
+	checkKeys(tree, reflect.TypeOf(out), "", loc, errs)
+	return errs.Aggregate()
+}
+
+// includeDefs merges the Definitions from the files included by def
+// recursively, file is the file containing def, and stack is the
+// absolute paths of the including files to detect cycles
+func (d *DefDecoder) includeDefs(def *CliDef, file string, stack []string) error {
+	if len(def.Include) == 0 {
+		return nil
+	}
+	if stack == nil && file != "" {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		stack = []string{abs}
+	}
+	if def.Definitions == nil {
+		def.Definitions = &Fragments{}
+	}
+	for _, name := range def.Include {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		for i, including := range stack {
+			if including == abs {
+				chain := append(append([]string{}, stack[i:]...), abs)
+				return errors.New(errMsgIncludeCycle + strings.Join(chain, " -> "))
+			}
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		included := &CliDef{}
+		if err = yaml.Unmarshal(content, included); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if included.Cli != nil {
+			return fmt.Errorf("%s: %s", path, errMsgIncludeCli)
+		}
+		if d.Strict {
+			loc := locateDefs(content)
+			if err = checkDefKeys(content, included, loc); err != nil {
+				decoder := *d
+				decoder.File = path
+				decoder.locateErrors(err, loc)
+				return err
+			}
+		}
+		if err = d.includeDefs(included, path, append(stack, abs)); err != nil {
+			return err
+		}
+		def.Definitions.include(included.Definitions)
+	}
+	return nil
+}
+
 func DecodeCmds(reader io.Reader) (*Command, error) {
 	if def, err := ioutil.ReadAll(reader); err != nil {
 		return nil, err
@@ -17,12 +198,7 @@ func DecodeCmds(reader io.Reader) (*Command, error) {
 }
 
 func DecodeCmdsBytes(def []byte) (*Command, error) {
-	cmd := &Command{}
-	if err := yaml.Unmarshal(def, cmd); err != nil {
-		return nil, err
-	} else {
-		return cmd, cmd.Normalize()
-	}
+	return NewDefDecoder().DecodeCmdsBytes(def)
 }
 
 func DecodeCmdsString(def string) (*Command, error) {
@@ -38,21 +214,11 @@ func DecodeCliDef(reader io.Reader) (*CliDef, error) {
 }
 
 func DecodeCliDefFile(filename string) (*CliDef, error) {
-	if f, err := os.Open(filename); err != nil {
-		return nil, err
-	} else {
-		defer f.Close()
-		return DecodeCliDef(f)
-	}
+	return NewDefDecoder().DecodeCliDefFile(filename)
 }
 
 func DecodeCliDefBytes(def []byte) (*CliDef, error) {
-	cliDef := &CliDef{}
-	if err := yaml.Unmarshal(def, cliDef); err != nil {
-		return nil, err
-	} else {
-		return cliDef, cliDef.Normalize()
-	}
+	return NewDefDecoder().DecodeCliDefBytes(def)
 }
 
 func DecodeCliDefString(def string) (*CliDef, error) {
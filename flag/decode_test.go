@@ -0,0 +1,239 @@
+package flag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	merr "github.com/easeway/langx.go/errors"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func defErrors(err error) []*CmdDefError {
+	var errs []*CmdDefError
+	switch e := err.(type) {
+	case *merr.AggregatedError:
+		for _, err := range e.Errors {
+			errs = append(errs, defErrors(err)...)
+		}
+	case *CmdDefError:
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+const badKeysDef = `---
+cli:
+  name: test
+  options:
+    - name: verbose
+      type: bool
+  commands:
+    - name: up
+      description: |
+        name: not a key
+      options:
+        - name: delay
+          type: int
+          requried: true
+    - name: down
+      summary: stop
+`
+
+func TestStrictKeys(t *testing.T) {
+	a := assert.New(t)
+	_, err := DecodeCliDefString(badKeysDef)
+	a.NoError(err)
+
+	def, err := NewDefDecoder().StrictKeys(true).FileName("test.yml").DecodeCliDefBytes([]byte(badKeysDef))
+	a.NotNil(def)
+	errs := defErrors(err)
+	if a.Len(errs, 2) {
+		a.Equal("cli.commands[0].options[0].requried", errs[0].Command)
+		a.Equal(14, errs[0].Line)
+		a.Equal(11, errs[0].Column)
+		a.Equal("Command Definition Error: test.yml:14:11: cli.commands[0].options[0].requried: unknown key", errs[0].Error())
+		a.Equal("cli.commands[1].summary", errs[1].Command)
+		a.Equal(16, errs[1].Line)
+		a.Equal(7, errs[1].Column)
+	}
+
+	_, err = NewDefDecoder().StrictKeys(true).DecodeCmdsBytes([]byte(`---
+name: cmd
+options:
+  - name: opt
+    default: 1
+    tags:
+      any: value
+`))
+	a.NoError(err)
+}
+
+func TestDefErrorLocation(t *testing.T) {
+	a := assert.New(t)
+	_, err := NewDefDecoder().FileName("test.yml").DecodeCliDefBytes([]byte(`---
+cli:
+  name: test
+  commands:
+    - name: up
+      options:
+        - name: delay
+          type: wrong-type
+    - description: no name
+    - name: down
+      commands:
+        - name: all
+          exclusive:
+            - [force]
+`))
+	errs := defErrors(err)
+	if a.Len(errs, 3) {
+		a.Equal("test/up[delay]", errs[0].Command)
+		a.Equal(7, errs[0].Line)
+		a.Equal(9, errs[0].Column)
+		a.Equal("test.yml:7:9: ", errs[0].Location())
+		a.Equal("test", errs[1].Command)
+		a.Equal(3, errs[1].Line)
+		a.Equal(3, errs[1].Column)
+		a.Equal("test/down/all", errs[2].Command)
+		a.Equal(12, errs[2].Line)
+		a.Equal(9, errs[2].Column)
+	}
+
+	_, err = DecodeCmdsString(`---
+name: cmd
+options:
+- name: a
+  type: string
+- name: b
+  alias: [a]
+`)
+	errs = defErrors(err)
+	if a.Len(errs, 1) {
+		a.Equal("cmd[b]", errs[0].Command)
+		a.Equal(6, errs[0].Line)
+		a.Equal(1, errs[0].Column)
+		a.Equal("", errs[0].File)
+	}
+}
+
+func TestDecodeFileErrors(t *testing.T) {
+	a := assert.New(t)
+	_, err := DecodeCliDefFile("test.yml")
+	a.NoError(err)
+
+	_, err = NewDefDecoder().FileName("bad.yml").DecodeCliDefBytes([]byte("cli: [\n"))
+	if a.Error(err) {
+		a.Contains(err.Error(), "bad.yml: ")
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	a := assert.New(t)
+	schema := JSONSchema()
+	defs := schema["definitions"].(map[string]interface{})
+	cmd := defs["Command"].(map[string]interface{})
+	a.NotContains(cmd, "required")
+	a.Equal(false, cmd["additionalProperties"])
+	props := cmd["properties"].(map[string]interface{})
+	a.Equal(map[string]interface{}{"$ref": "#/definitions/Command"},
+		props["commands"].(map[string]interface{})["items"])
+	a.Contains(props, "stop-at-args")
+	a.NotContains(props, "OptMap")
+	opt := defs["Option"].(map[string]interface{})["properties"].(map[string]interface{})
+	a.Equal(map[string]interface{}{}, opt["default"])
+	a.Contains(opt, "default-desc")
+	a.NotContains(opt, "vtype")
+
+	published, err := ioutil.ReadFile("../schema/clidef.schema.json")
+	if a.NoError(err) {
+		a.Equal(string(JSONSchemaBytes()), string(published), "schema/clidef.schema.json is outdated")
+	}
+}
+
+// validateSchema checks val against the subset of JSON Schema used by
+// schema/clidef.schema.json and returns the paths of the violations
+func validateSchema(val interface{}, schema, root map[string]interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		def := root["definitions"].(map[string]interface{})[name]
+		return validateSchema(val, def.(map[string]interface{}), root, path)
+	}
+	switch schema["type"] {
+	case "string":
+		if _, ok := val.(string); !ok {
+			return []string{path + ": not a string"}
+		}
+	case "boolean":
+		if _, ok := val.(bool); !ok {
+			return []string{path + ": not a boolean"}
+		}
+	case "integer":
+		if _, ok := val.(int); !ok {
+			return []string{path + ": not an integer"}
+		}
+	case "array":
+		items, ok := val.([]interface{})
+		if !ok {
+			return []string{path + ": not an array"}
+		}
+		var errs []string
+		for i, item := range items {
+			errs = append(errs, validateSchema(item,
+				schema["items"].(map[string]interface{}), root,
+				fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	case "object":
+		m, ok := val.(map[interface{}]interface{})
+		if !ok {
+			return []string{path + ": not an object"}
+		}
+		var errs []string
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, key := range required {
+				if _, exists := m[key]; !exists {
+					errs = append(errs, fmt.Sprintf("%s: missing %v", path, key))
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		for k, v := range m {
+			key := fmt.Sprint(k)
+			if prop, ok := props[key]; ok {
+				errs = append(errs, validateSchema(v, prop.(map[string]interface{}), root, path+"."+key)...)
+			} else if extra, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				errs = append(errs, validateSchema(v, extra, root, path+"."+key)...)
+			} else if schema["additionalProperties"] == false {
+				errs = append(errs, path+": unknown "+key)
+			}
+		}
+		return errs
+	}
+	return nil
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	a := assert.New(t)
+	published, err := ioutil.ReadFile("../schema/clidef.schema.json")
+	if !a.NoError(err) {
+		return
+	}
+	var schema map[string]interface{}
+	if !a.NoError(json.Unmarshal(published, &schema)) {
+		return
+	}
+	var doc interface{}
+	if a.NoError(yaml.Unmarshal([]byte(fragmentsDef), &doc)) {
+		a.Empty(validateSchema(doc, schema, schema, ""))
+	}
+	if a.NoError(yaml.Unmarshal([]byte(badKeysDef), &doc)) {
+		a.Equal([]string{
+			".cli.commands[0].options[0]: unknown requried",
+			".cli.commands[1]: unknown summary",
+		}, validateSchema(doc, schema, schema, ""))
+	}
+}
@@ -0,0 +1,108 @@
+package flag
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultFunc computes the default value of an option when a command
+// is being parsed, the value is converted like Option.Default
+type DefaultFunc func() (interface{}, error)
+
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandString replaces ${NAME} with the value of environment variable
+// NAME, and a leading ~ with the home directory of current user
+func ExpandString(str string) string {
+	str = envRefPattern.ReplaceAllStringFunc(str, func(ref string) string {
+		return os.Getenv(ref[2 : len(ref)-1])
+	})
+	if str == "~" || strings.HasPrefix(str, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			str = filepath.Join(home, str[1:])
+		}
+	}
+	return str
+}
+
+func needsExpand(val interface{}) bool {
+	switch v := val.(type) {
+	case string:
+		return v == "~" || strings.HasPrefix(v, "~/") || envRefPattern.MatchString(v)
+	case []interface{}:
+		for _, item := range v {
+			if needsExpand(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandDefault expands the strings in the default value
+func expandDefault(val interface{}) interface{} {
+	switch v := val.(type) {
+	case string:
+		return ExpandString(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = expandDefault(item)
+		}
+		return items
+	}
+	return val
+}
+
+// IsComputedDefault tells whether the default value is computed when
+// parsing, from DefaultFunc or by expanding the strings in Default
+func (opt *Option) IsComputedDefault() bool {
+	return opt.DefaultFunc != nil || needsExpand(opt.Default)
+}
+
+// ResolveDefault computes the default value using DefaultFunc if present,
+// otherwise from Default with strings expanded, see ExpandString.
+// The value is nil if there's no default.
+func (opt *Option) ResolveDefault() (interface{}, error) {
+	val := opt.Default
+	if opt.DefaultFunc != nil {
+		v, err := opt.DefaultFunc()
+		if err != nil {
+			return nil, err
+		}
+		val = v
+	} else {
+		val = expandDefault(val)
+	}
+	if val == nil {
+		return nil, nil
+	}
+	return opt.ParseVal(val)
+}
+
+// computeDefaults overrides the default values which are computed, the
+// failed ones keep the values from the definition and are reported
+func (cmd *Command) computeDefaults(vars map[string]interface{}) []*VarError {
+	var errs []*VarError
+	for _, opts := range [][]*Option{cmd.Options, cmd.Arguments} {
+		for _, opt := range opts {
+			if opt.Required || !opt.IsComputedDefault() {
+				continue
+			}
+			val, err := opt.ResolveDefault()
+			if err != nil {
+				varErr := &VarError{Name: opt.Name, Def: opt, ErrType: VarErrBadVal, Reason: err.Error()}
+				if str, ok := opt.Default.(string); ok && opt.DefaultFunc == nil {
+					expanded := ExpandString(str)
+					varErr.Value = &expanded
+				}
+				errs = append(errs, varErr)
+			} else if val != nil {
+				vars[opt.Name] = val
+			}
+		}
+	}
+	return errs
+}
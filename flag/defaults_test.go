@@ -0,0 +1,122 @@
+package flag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandString(t *testing.T) {
+	a := assert.New(t)
+	os.Setenv("CLIX_TEST_EXPAND", "x")
+	defer os.Unsetenv("CLIX_TEST_EXPAND")
+	a.Equal("a/x/b", ExpandString("a/${CLIX_TEST_EXPAND}/b"))
+	a.Equal("$CLIX_TEST_EXPAND-", ExpandString("$CLIX_TEST_EXPAND-${CLIX_TEST_UNDEFINED}"))
+	a.Equal("a~", ExpandString("a~"))
+	if home, err := os.UserHomeDir(); err == nil {
+		a.Equal(home, ExpandString("~"))
+		a.Equal(filepath.Join(home, ".cache", "x"), ExpandString("~/.cache/${CLIX_TEST_EXPAND}"))
+	}
+}
+
+func TestComputedDefaults(t *testing.T) {
+	a := assert.New(t)
+	def, err := DecodeCliDefString(`---
+cli:
+    name: test
+    options:
+        - name: cache
+          default: '${CLIX_TEST_CACHE}/tool'
+        - name: dirs
+          list: true
+          default: ['${CLIX_TEST_CACHE}', b]
+        - name: branch
+          default: master
+          default-desc: current branch
+        - name: plain
+          default: '$HOME'
+`)
+	if !a.NoError(err) {
+		return
+	}
+	os.Setenv("CLIX_TEST_CACHE", "/tmp/c1")
+	defer os.Unsetenv("CLIX_TEST_CACHE")
+
+	branch := "dev"
+	def.Cli.FindOption("branch").DefaultFunc = func() (interface{}, error) {
+		if branch == "" {
+			return nil, errors.New("not a git repository")
+		}
+		return branch, nil
+	}
+
+	r := def.ParseArgs("test")
+	if a.False(r.HasErrors()) {
+		vars := r.CmdStack[0].Vars
+		a.Equal("/tmp/c1/tool", vars["cache"])
+		a.Equal([]interface{}{"/tmp/c1", "b"}, vars["dirs"])
+		a.Equal("dev", vars["branch"])
+		a.Equal("$HOME", vars["plain"])
+	}
+	a.Equal("/tmp/c1/tool", def.Cli.FindOption("cache").DefaultAsString())
+
+	// evaluated every time a command is parsed
+	os.Setenv("CLIX_TEST_CACHE", "/tmp/c2")
+	branch = ""
+	r = def.ParseArgs("test", "--dirs=d")
+	if a.True(r.HasErrors()) && a.Len(r.CmdStack[0].Errs, 1) {
+		vars := r.CmdStack[0].Vars
+		a.Equal("/tmp/c2/tool", vars["cache"])
+		a.Equal([]interface{}{"/tmp/c2", "b", "d"}, vars["dirs"])
+		// fallback to the value in definition
+		a.Equal("master", vars["branch"])
+		e := r.CmdStack[0].Errs[0]
+		a.Equal(VarErrBadVal, e.ErrType)
+		a.Equal("branch", e.Name)
+		a.Equal("not a git repository", e.Reason)
+	}
+
+	// the failed default is not used when a value is given
+	r = def.ParseArgs("test", "--branch=dev")
+	a.False(r.HasErrors())
+}
+
+func TestTypedComputedDefaults(t *testing.T) {
+	a := assert.New(t)
+	def, err := DecodeCliDefString(`---
+cli:
+    name: test
+    options:
+        - name: port
+          type: int
+          default: '${CLIX_TEST_PORT}'
+`)
+	if !a.NoError(err) {
+		return
+	}
+	os.Setenv("CLIX_TEST_PORT", "8080")
+	r := def.ParseArgs("test")
+	if a.False(r.HasErrors()) {
+		a.Equal(int64(8080), r.CmdStack[0].Vars["port"])
+	}
+
+	os.Unsetenv("CLIX_TEST_PORT")
+	r = def.ParseArgs("test")
+	if a.Len(r.CmdStack[0].Errs, 1) {
+		e := r.CmdStack[0].Errs[0]
+		a.Equal(VarErrBadVal, e.ErrType)
+		a.Equal("port", e.Name)
+		if a.NotNil(e.Value) {
+			a.Empty(*e.Value)
+		}
+		a.Equal(int64(0), r.CmdStack[0].Vars["port"])
+	}
+
+	r = def.ParseArgs("test", "--port=1")
+	if a.False(r.HasErrors()) {
+		a.Equal(int64(1), r.CmdStack[0].Vars["port"])
+	}
+}
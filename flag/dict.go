@@ -0,0 +1,112 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// normalizeDict validates SubType of dict (e.g. dict/int) and
+// creates the option parsing the values
+func (opt *Option) normalizeDict(cmdPath string) error {
+	opt.item = nil
+	if opt.SubType == "" {
+		return nil
+	}
+	item := &Option{Name: opt.Name, Type: opt.SubType}
+	if err := item.normalizeType(cmdPath); err != nil ||
+		item.ValueKind == reflect.Map || item.IsCount || item.SubType != "" {
+		return opt.defError(cmdPath, errMsgInvalidType+opt.Type+"/"+opt.SubType)
+	}
+	opt.item = item
+	return nil
+}
+
+// parseDictEntry parses KEY=VALUE into a dict, the value is parsed by
+// SubType, and a dotted KEY like a.b.c builds nested dicts if NestedKeys.
+// KEY alone is the same as KEY=true if SubType is empty or bool
+func (opt *Option) parseDictEntry(entry string) (map[string]interface{}, error) {
+	key := entry
+	var val interface{} = true
+	if pos := strings.IndexByte(entry, '='); pos >= 0 {
+		key = entry[:pos]
+		val = entry[pos+1:]
+		if opt.item != nil {
+			parsedVal, err := opt.item.parseStrVal(entry[pos+1:])
+			if err != nil {
+				return nil, err
+			}
+			val = parsedVal
+		}
+	} else if opt.item != nil && opt.item.ValueKind != reflect.Bool {
+		return nil, errors.New(errMsgDictNoValue + key)
+	}
+	keys := []string{key}
+	if opt.NestedKeys {
+		keys = strings.Split(key, ".")
+	}
+	for _, k := range keys {
+		if k == "" {
+			return nil, errors.New(errMsgNameEmpty)
+		}
+	}
+	dict := map[string]interface{}{keys[len(keys)-1]: val}
+	for i := len(keys) - 2; i >= 0; i-- {
+		dict = map[string]interface{}{keys[i]: dict}
+	}
+	return dict, nil
+}
+
+// convertDict converts KEY=VALUE or a map, e.g. decoded from YAML/JSON,
+// to map[string]interface{} recursively, values are converted by SubType
+func (opt *Option) convertDict(val interface{}) (map[string]interface{}, error) {
+	if str, ok := val.(string); ok {
+		return opt.parseDictEntry(str)
+	}
+	sv := reflect.ValueOf(val)
+	if sv.Kind() != reflect.Map {
+		return nil, errors.New(errMsgInvalidType + sv.Kind().String())
+	}
+	dict := make(map[string]interface{})
+	for _, kv := range sv.MapKeys() {
+		vv := sv.MapIndex(kv)
+		if !kv.CanInterface() || !vv.CanInterface() {
+			return nil, errors.New(errMsgInvalidType + sv.Kind().String())
+		}
+		key := fmt.Sprintf("%v", kv.Interface())
+		v := vv.Interface()
+		if v != nil && reflect.ValueOf(v).Kind() == reflect.Map {
+			nested, err := opt.convertDict(v)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = nested
+		} else if opt.item != nil {
+			converted, err := opt.item.convert(v)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = converted
+		} else {
+			dict[key] = v
+		}
+	}
+	return dict, nil
+}
+
+// mergeDict merges src into dest, nested dicts are merged into copies
+// as they may be shared with default values
+func mergeDict(dest, src map[string]interface{}) {
+	for k, v := range src {
+		if srcDict, ok := v.(map[string]interface{}); ok {
+			if destDict, ok := dest[k].(map[string]interface{}); ok {
+				merged := make(map[string]interface{})
+				mergeDict(merged, destDict)
+				mergeDict(merged, srcDict)
+				v = merged
+			}
+		}
+		dest[k] = v
+	}
+}
@@ -5,10 +5,33 @@ import (
 )
 
 const (
-	errMsgInvalidType  = "invalid type: "
-	errMsgNameEmpty    = "name should not be empty"
-	errMsgDupName      = "name/alias duplicated"
-	errMsgNameTooShort = "name should be long name, short name comes in alias"
+	errMsgInvalidType    = "invalid type: "
+	errMsgNameEmpty      = "name should not be empty"
+	errMsgDupName        = "name/alias duplicated"
+	errMsgNameTooShort   = "name should be long name, short name comes in alias"
+	errMsgInvalidEnv     = "invalid environment variable name: "
+	errMsgListArgNotLast = "only the last argument can be a list"
+	errMsgUnknownKey     = "unknown key"
+	errMsgDictNoValue    = "missing value of key: "
+
+	errMsgRuleNotApplicable = "rule not applicable to the type: "
+	errMsgInvalidRule       = "invalid rule: "
+
+	errMsgConstraintTooFew = "constraint requires at least %d options"
+	errMsgConstraintNoOpt  = "constraint refers to unknown option: "
+
+	errMsgReplacementNoOpt = "replaced by unknown option: "
+	errMsgReplacementNoCmd = "replaced by unknown command: "
+
+	errMsgRefUnknown     = "unknown reference: "
+	errMsgRefCycle       = "circular reference: "
+	errMsgRefSetOverride = "option set can't be overridden: "
+	errMsgIncludeCycle   = "circular include: "
+	errMsgIncludeCli     = "included file can't define cli"
+
+	errMsgRecordNoCmd   = "unknown command in record: "
+	errMsgRecordErrType = "unknown error type in record: "
+	errMsgRecordNoDef   = "unknown option in record: "
 )
 
 var (
@@ -56,12 +56,40 @@ func (c *ParseContext) CmdAt(at int) *ParsedCmd {
 func (c *ParseContext) SetVarAt(at int, name string, val interface{}) *ParseContext {
 	if pcmd := c.CmdAt(at); pcmd != nil {
 		pcmd.Vars[name] = val
+		pcmd.Sources[name] = VarSrcExt
 	}
 	return c
 }
 
 func (c *ParseContext) SetVar(name string, val interface{}) *ParseContext {
-	c.CurrentCmd().Vars[name] = val
+	pcmd := c.CurrentCmd()
+	pcmd.Vars[name] = val
+	pcmd.Sources[name] = VarSrcExt
+	return c
+}
+
+// SetSourceAt changes the source (VarSrcXXX) of the value set to the
+// command at specified position, e.g. VarSrcConfig
+func (c *ParseContext) SetSourceAt(at int, name, src string) *ParseContext {
+	if pcmd := c.CmdAt(at); pcmd != nil {
+		pcmd.Sources[name] = src
+	}
+	return c
+}
+
+// AssignVarAt sets the parsed value of an option to the command at specified
+// position and notifies the extensions listening on EvtAssigned
+func (c *ParseContext) AssignVarAt(at int, opt *Option, val interface{}) *ParseContext {
+	if pcmd := c.CmdAt(at); pcmd != nil {
+		pcmd.Vars[opt.Name] = val
+		pcmd.Sources[opt.Name] = VarSrcExt
+		c.parser.invokeExts(EvtAssigned, &ParseContext{
+			OptionAt: at,
+			Option:   opt,
+			Name:     opt.Name,
+			Assigned: val,
+		})
+	}
 	return c
 }
 
@@ -70,6 +98,12 @@ func (c *ParseContext) PushBack(args ...string) *ParseContext {
 	return c
 }
 
+// PushedArgs returns the number of args pushed back by PushBack which are
+// not parsed yet, including the current one if it was pushed back
+func (c *ParseContext) PushedArgs() int {
+	return c.parser.pushed
+}
+
 type ExecContext struct {
 	Result *ParseResult
 
@@ -0,0 +1,368 @@
+package flag
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// refKey is the key referencing the fragments in YAML
+const refKey = "$ref"
+
+// Fragments are the reusable definitions referenced by $ref,
+// the name of a definition defaults to its key if not specified
+type Fragments struct {
+	Options map[string]*Option `yaml:"options,omitempty"`
+	// OptionSets are the lists of options referenced as a whole
+	OptionSets map[string][]*Option `yaml:"option-sets,omitempty"`
+	// Arguments are looked up before Options when referenced by arguments
+	Arguments map[string]*Option  `yaml:"arguments,omitempty"`
+	Commands  map[string]*Command `yaml:"commands,omitempty"`
+}
+
+// include adds the definitions from other which are not defined yet
+func (f *Fragments) include(other *Fragments) {
+	if other == nil {
+		return
+	}
+	if f.Options == nil {
+		f.Options = make(map[string]*Option)
+	}
+	for name, opt := range other.Options {
+		if _, exists := f.Options[name]; !exists {
+			f.Options[name] = opt
+		}
+	}
+	if f.OptionSets == nil {
+		f.OptionSets = make(map[string][]*Option)
+	}
+	for name, set := range other.OptionSets {
+		if _, exists := f.OptionSets[name]; !exists {
+			f.OptionSets[name] = set
+		}
+	}
+	if f.Arguments == nil {
+		f.Arguments = make(map[string]*Option)
+	}
+	for name, arg := range other.Arguments {
+		if _, exists := f.Arguments[name]; !exists {
+			f.Arguments[name] = arg
+		}
+	}
+	if f.Commands == nil {
+		f.Commands = make(map[string]*Command)
+	}
+	for name, cmd := range other.Commands {
+		if _, exists := f.Commands[name]; !exists {
+			f.Commands[name] = cmd
+		}
+	}
+}
+
+// ResolveRefs replaces the options, arguments and commands with $ref by
+// the copies of referenced Definitions, an option set is expanded into
+// all the options in the set
+func (d *CliDef) ResolveRefs() error {
+	if d.Cli == nil {
+		return nil
+	}
+	r := &refResolver{defs: d.Definitions}
+	if r.defs == nil {
+		r.defs = &Fragments{}
+	}
+	if d.Cli.Ref != "" {
+		cmd, err := r.commandRef(d.Cli, "")
+		if err != nil {
+			return err
+		}
+		d.Cli = cmd
+		return nil
+	}
+	return r.resolveCmd(d.Cli, "")
+}
+
+// UnmarshalYAML decodes the option and records the keys along with $ref
+func (opt *Option) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Option
+	if err := unmarshal((*plain)(opt)); err != nil {
+		return err
+	}
+	opt.refKeys = nil
+	if opt.Ref != "" {
+		opt.refKeys = decodedKeys(unmarshal)
+	}
+	return nil
+}
+
+// UnmarshalYAML decodes the command and records the keys along with $ref
+func (cmd *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Command
+	if err := unmarshal((*plain)(cmd)); err != nil {
+		return err
+	}
+	cmd.refKeys = nil
+	if cmd.Ref != "" {
+		cmd.refKeys = decodedKeys(unmarshal)
+	}
+	return nil
+}
+
+func decodedKeys(unmarshal func(interface{}) error) map[string]bool {
+	var m map[interface{}]interface{}
+	if err := unmarshal(&m); err != nil {
+		return nil
+	}
+	keys := make(map[string]bool)
+	for k := range m {
+		keys[fmt.Sprint(k)] = true
+	}
+	return keys
+}
+
+type refResolver struct {
+	defs *Fragments
+	// refs are the references being resolved, to detect cycles
+	refs []string
+}
+
+func (r *refResolver) enter(kind, name, cmdPath string) error {
+	ref := kind + "/" + name
+	for i, entered := range r.refs {
+		if entered == ref {
+			chain := append(append([]string{}, r.refs[i:]...), ref)
+			return &CmdDefError{Command: cmdPath, Message: errMsgRefCycle + strings.Join(chain, " -> ")}
+		}
+	}
+	r.refs = append(r.refs, ref)
+	return nil
+}
+
+func (r *refResolver) leave() {
+	r.refs = r.refs[:len(r.refs)-1]
+}
+
+func (r *refResolver) resolveCmd(cmd *Command, cmdPath string) error {
+	if cmdPath != "" {
+		cmdPath += "/"
+	}
+	cmdPath += cmd.Name
+	var err error
+	if cmd.Options, err = r.resolveOpts(cmd.Options, cmdPath, false); err != nil {
+		return err
+	}
+	if cmd.Arguments, err = r.resolveOpts(cmd.Arguments, cmdPath, true); err != nil {
+		return err
+	}
+	for i, sub := range cmd.Commands {
+		if sub.Ref != "" {
+			if cmd.Commands[i], err = r.commandRef(sub, cmdPath); err != nil {
+				return err
+			}
+		} else if err = r.resolveCmd(sub, cmdPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commandRef resolves the command referenced by item
+func (r *refResolver) commandRef(item *Command, cmdPath string) (*Command, error) {
+	if err := r.enter("commands", item.Ref, cmdPath); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	def, ok := r.defs.Commands[item.Ref]
+	if !ok {
+		return nil, &CmdDefError{Command: cmdPath, Message: errMsgRefUnknown + item.Ref}
+	}
+	cmd := copyCmd(def)
+	if cmd.Ref != "" {
+		base, err := r.commandRef(cmd, cmdPath)
+		if err != nil {
+			return nil, err
+		}
+		cmd = base
+	}
+	overlayFields(reflect.ValueOf(cmd).Elem(), reflect.ValueOf(item).Elem(), item.refKeys)
+	cmd.Ref = ""
+	cmd.refKeys = nil
+	if cmd.Name == "" {
+		cmd.Name = item.Ref
+	}
+	return cmd, r.resolveCmd(cmd, cmdPath)
+}
+
+// resolveOpts returns opts if there's no reference,
+// otherwise a new list with the references expanded
+func (r *refResolver) resolveOpts(opts []*Option, cmdPath string, isArg bool) ([]*Option, error) {
+	var resolved []*Option
+	for i, opt := range opts {
+		if opt.Ref == "" {
+			if resolved != nil {
+				resolved = append(resolved, opt)
+			}
+			continue
+		}
+		if resolved == nil {
+			resolved = append([]*Option{}, opts[:i]...)
+		}
+		expanded, err := r.optionRef(opt, cmdPath, isArg)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, expanded...)
+	}
+	if resolved == nil {
+		return opts, nil
+	}
+	return resolved, nil
+}
+
+// optionRef resolves the option or option set referenced by item
+func (r *refResolver) optionRef(item *Option, cmdPath string, isArg bool) ([]*Option, error) {
+	name := item.Ref
+	kind, def := "options", r.defs.Options[name]
+	if arg, ok := r.defs.Arguments[name]; ok && isArg {
+		kind, def = "arguments", arg
+	}
+	if def == nil {
+		set, ok := r.defs.OptionSets[name]
+		if !ok {
+			return nil, &CmdDefError{Command: cmdPath, Message: errMsgRefUnknown + name}
+		}
+		if hasOverrides(reflect.ValueOf(item).Elem(), item.refKeys) {
+			return nil, &CmdDefError{Command: cmdPath, Message: errMsgRefSetOverride + name}
+		}
+		if err := r.enter("option-sets", name, cmdPath); err != nil {
+			return nil, err
+		}
+		defer r.leave()
+		return r.resolveOpts(copyOpts(set), cmdPath, isArg)
+	}
+	if err := r.enter(kind, name, cmdPath); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	opt := copyOpt(def)
+	opts := []*Option{opt}
+	if opt.Ref != "" {
+		var err error
+		if opts, err = r.optionRef(opt, cmdPath, isArg); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts) != 1 {
+		if hasOverrides(reflect.ValueOf(item).Elem(), item.refKeys) {
+			return nil, &CmdDefError{Command: cmdPath, Message: errMsgRefSetOverride + name}
+		}
+		return opts, nil
+	}
+	overlayFields(reflect.ValueOf(opts[0]).Elem(), reflect.ValueOf(item).Elem(), item.refKeys)
+	opts[0].Ref = ""
+	opts[0].refKeys = nil
+	if opts[0].Name == "" {
+		opts[0].Name = name
+	}
+	return opts, nil
+}
+
+// copyOpt copies the option with the slices and maps, which are changed
+// by Normalize or the fields set along with $ref
+func copyOpt(opt *Option) *Option {
+	c := *opt
+	c.Alias = append([]string(nil), opt.Alias...)
+	c.Choices = append([]interface{}(nil), opt.Choices...)
+	c.Tags = copyTags(opt.Tags)
+	return &c
+}
+
+func copyOpts(opts []*Option) []*Option {
+	if opts == nil {
+		return nil
+	}
+	copied := make([]*Option, len(opts))
+	for i, opt := range opts {
+		copied[i] = copyOpt(opt)
+	}
+	return copied
+}
+
+func copyTags(tags map[string]interface{}) map[string]interface{} {
+	if tags == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+	return copied
+}
+
+func copyGroups(groups [][]string) [][]string {
+	if groups == nil {
+		return nil
+	}
+	copied := make([][]string, len(groups))
+	for i, group := range groups {
+		copied[i] = append([]string(nil), group...)
+	}
+	return copied
+}
+
+// copyCmd copies the command with the options, subcommands,
+// and the other slices and maps
+func copyCmd(cmd *Command) *Command {
+	c := *cmd
+	c.Alias = append([]string(nil), cmd.Alias...)
+	c.Tags = copyTags(cmd.Tags)
+	c.Exclusive = copyGroups(cmd.Exclusive)
+	c.AtLeastOne = copyGroups(cmd.AtLeastOne)
+	if cmd.Requires != nil {
+		c.Requires = make(map[string][]string, len(cmd.Requires))
+		for name, opts := range cmd.Requires {
+			c.Requires[name] = append([]string(nil), opts...)
+		}
+	}
+	c.Options = copyOpts(cmd.Options)
+	c.Arguments = copyOpts(cmd.Arguments)
+	if cmd.Commands != nil {
+		c.Commands = make([]*Command, len(cmd.Commands))
+		for i, sub := range cmd.Commands {
+			c.Commands[i] = copyCmd(sub)
+		}
+	}
+	return &c
+}
+
+// overlayFields sets the fields of dst to the ones of src which are
+// decoded from keys, or the non-zero ones if src is not decoded
+func overlayFields(dst, src reflect.Value, keys map[string]bool) {
+	for _, f := range yamlFields(dst.Type()) {
+		if v := src.Field(f.index); isOverride(f, v, keys) {
+			dst.Field(f.index).Set(v)
+		}
+	}
+}
+
+func hasOverrides(v reflect.Value, keys map[string]bool) bool {
+	for _, f := range yamlFields(v.Type()) {
+		if isOverride(f, v.Field(f.index), keys) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOverride(f yamlField, v reflect.Value, keys map[string]bool) bool {
+	if f.name == refKey {
+		return false
+	}
+	if keys != nil {
+		return keys[f.name]
+	}
+	return !isZeroValue(v)
+}
+
+func isZeroValue(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
@@ -0,0 +1,294 @@
+package flag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fragmentsDef = `---
+definitions:
+  options:
+    output:
+      alias: [o]
+      description: output file
+    server:
+      name: server
+      alias: [s]
+    target: {}
+  option-sets:
+    conn:
+      - $ref: server
+      - name: timeout
+        type: duration
+  arguments:
+    file:
+      description: input file
+      required: true
+  commands:
+    status:
+      description: show status
+      options:
+        - $ref: output
+cli:
+  name: app
+  options:
+    - $ref: conn
+    - name: verbose
+      type: bool
+  commands:
+    - name: up
+      options:
+        - $ref: output
+          required: true
+      arguments:
+        - $ref: file
+        - $ref: target
+    - $ref: status
+    - $ref: status
+      name: info
+`
+
+func TestFragments(t *testing.T) {
+	a := assert.New(t)
+	def, err := NewDefDecoder().StrictKeys(true).DecodeCliDefBytes([]byte(fragmentsDef))
+	if !a.NoError(err) {
+		return
+	}
+	cmd := def.Cli
+	if a.Len(cmd.Options, 3) {
+		a.Equal("server", cmd.Options[0].Name)
+		a.Equal([]string{"s"}, cmd.Options[0].Alias)
+		a.Equal("timeout", cmd.Options[1].Name)
+		a.Equal("verbose", cmd.Options[2].Name)
+		a.Empty(cmd.Options[0].Ref)
+	}
+	if !a.Len(cmd.Commands, 3) {
+		return
+	}
+	up := cmd.Commands[0]
+	a.Equal("output", up.Options[0].Name)
+	a.Equal([]string{"o"}, up.Options[0].Alias)
+	a.True(up.Options[0].Required)
+	a.False(def.Definitions.Options["output"].Required)
+	if a.Len(up.Arguments, 2) {
+		a.Equal("file", up.Arguments[0].Name)
+		a.True(up.Arguments[0].Required)
+		a.Equal("target", up.Arguments[1].Name)
+		a.True(up.Arguments[1].IsArg)
+	}
+	status, info := cmd.Commands[1], cmd.Commands[2]
+	a.Equal("status", status.Name)
+	a.Equal("show status", status.Desc)
+	a.Equal("info", info.Name)
+	a.Equal("show status", info.Desc)
+	a.True(status.Options[0] != info.Options[0])
+
+	r := def.ParseArgs("app", "-s", "srv", "info", "-o", "out")
+	if a.NoError(r.Error) && a.False(r.HasErrors()) {
+		a.Equal("srv", r.CmdStack[0].Vars["server"])
+		a.Equal("out", r.CmdStack[1].Vars["output"])
+	}
+}
+
+func TestFragmentOverrides(t *testing.T) {
+	a := assert.New(t)
+	def, err := DecodeCliDefString(`---
+definitions:
+  options:
+    format:
+      alias: [f]
+      required: true
+      hidden: true
+      default: json
+      choices: [json, yaml]
+      tags:
+        group: output
+cli:
+  name: app
+  commands:
+    - name: get
+      options:
+        - $ref: format
+          required: false
+          hidden: false
+          default: null
+    - name: list
+      options:
+        - $ref: format
+`)
+	if !a.NoError(err) {
+		return
+	}
+	get := def.Cli.FindCommand("get").FindOption("format")
+	a.False(get.Required)
+	a.False(get.Hidden)
+	a.Nil(get.Default)
+	list := def.Cli.FindCommand("list").FindOption("format")
+	a.True(list.Required)
+	a.True(list.Hidden)
+	a.Equal("json", list.Default)
+
+	// the copies don't share slices and maps
+	get.Alias[0] = "g"
+	get.Choices[0] = "xml"
+	get.Tags["group"] = "get"
+	a.Equal([]string{"f"}, list.Alias)
+	a.Equal([]interface{}{"json", "yaml"}, list.Choices)
+	a.Equal("output", list.Tags["group"])
+	a.Equal([]string{"f"}, def.Definitions.Options["format"].Alias)
+}
+
+func TestFragmentErrors(t *testing.T) {
+	a := assert.New(t)
+	errMsg := func(def string) string {
+		_, err := DecodeCliDefString(def)
+		if errs := defErrors(err); a.Len(errs, 1) {
+			return errs[0].Command + ": " + errs[0].Message
+		}
+		return ""
+	}
+	a.Equal("app: unknown reference: none", errMsg(`---
+cli:
+  name: app
+  options:
+    - $ref: none
+`))
+	a.Equal("app/up: circular reference: options/a -> options/b -> options/a", errMsg(`---
+definitions:
+  options:
+    a: {$ref: b}
+    b: {$ref: a}
+cli:
+  name: app
+  commands:
+    - name: up
+      options:
+        - $ref: a
+`))
+	a.Equal("app/loop: circular reference: commands/loop -> commands/loop", errMsg(`---
+definitions:
+  commands:
+    loop:
+      commands:
+        - $ref: loop
+cli:
+  name: app
+  commands:
+    - $ref: loop
+`))
+	a.Equal("app: option set can't be overridden: set", errMsg(`---
+definitions:
+  option-sets:
+    set:
+      - name: opt
+cli:
+  name: app
+  options:
+    - $ref: set
+      required: true
+`))
+}
+
+func TestFragmentsNormalize(t *testing.T) {
+	a := assert.New(t)
+	def := &CliDef{
+		Definitions: &Fragments{
+			Options: map[string]*Option{"name": &Option{Alias: []string{"n"}}},
+		},
+		Cli: &Command{
+			Name:    "app",
+			Options: []*Option{&Option{Ref: "name"}},
+		},
+	}
+	a.NoError(def.Normalize())
+	a.NotNil(def.Cli.FindOption("n"))
+	a.NoError(def.Normalize())
+	a.Len(def.Cli.Options, 1)
+}
+
+func TestIncludes(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "clix-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = os.Mkdir(filepath.Join(dir, "common"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeRespFile(t, dir, "cli.yml", `---
+include: [common/opts.yml]
+definitions:
+  options:
+    server:
+      name: server
+      alias: [S]
+cli:
+  name: app
+  options:
+    - $ref: server
+    - $ref: timeout
+  commands:
+    - $ref: status
+`)
+	writeRespFile(t, dir, "common/opts.yml", `---
+include: [cmds.yml]
+definitions:
+  options:
+    server:
+      alias: [s]
+    timeout:
+      type: duration
+    format:
+      alias: [f]
+`)
+	writeRespFile(t, dir, "common/cmds.yml", `---
+definitions:
+  commands:
+    status:
+      options:
+        - $ref: format
+`)
+	def, err := DecodeCliDefFile(filepath.Join(dir, "cli.yml"))
+	if a.NoError(err) {
+		a.Equal([]string{"S"}, def.Cli.Options[0].Alias)
+		a.Equal("timeout", def.Cli.Options[1].Name)
+		a.Equal("status", def.Cli.Commands[0].Name)
+		a.Equal("format", def.Cli.Commands[0].Options[0].Name)
+	}
+
+	writeRespFile(t, dir, "common/cmds.yml", `---
+include: [../cli.yml]
+`)
+	_, err = DecodeCliDefFile(filepath.Join(dir, "cli.yml"))
+	if a.Error(err) {
+		a.Contains(err.Error(), "circular include: ")
+	}
+
+	writeRespFile(t, dir, "common/cmds.yml", `---
+cli:
+  name: other
+`)
+	_, err = DecodeCliDefFile(filepath.Join(dir, "cli.yml"))
+	if a.Error(err) {
+		a.Contains(err.Error(), "cmds.yml: included file can't define cli")
+	}
+
+	writeRespFile(t, dir, "common/cmds.yml", `---
+definitions:
+  commands:
+    status:
+      descr: typo
+`)
+	_, err = NewDefDecoder().StrictKeys(true).DecodeCliDefFile(filepath.Join(dir, "cli.yml"))
+	if errs := defErrors(err); a.Len(errs, 1) {
+		a.Equal(filepath.Join(dir, "common", "cmds.yml"), errs[0].File)
+		a.Equal("definitions.commands.status.descr", errs[0].Command)
+		a.Equal(5, errs[0].Line)
+	}
+}
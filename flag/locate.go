@@ -0,0 +1,128 @@
+package flag
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defPos is the position in a definition file, starting from 1
+type defPos struct {
+	line, col int
+}
+
+// defLocator locates the keys and definitions of commands/options in
+// YAML by scanning the lines, only block style collections are tracked
+type defLocator struct {
+	// keys maps paths like cli.commands[0].name to positions
+	keys map[string]defPos
+	// defs maps paths like test/up[delay] (see CmdDefError.Command)
+	// to the positions of definitions
+	defs map[string]defPos
+}
+
+type locFrame struct {
+	indent int
+	item   bool
+	key    string
+	path   string
+	pos    defPos
+	// count is the number of items in the sequence under the key
+	count int
+	// cmdDef is the path of the command containing the frame
+	cmdDef string
+}
+
+var locKeyPattern = regexp.MustCompile(`^(?:"([^"]*)"|'([^']*)'|([^\s"'#{\[][^:#]*?))\s*:(?:\s+(.*))?$`)
+
+func locateDefs(def []byte) *defLocator {
+	l := &defLocator{keys: make(map[string]defPos), defs: make(map[string]defPos)}
+	var stack []*locFrame
+	top := func() *locFrame {
+		if len(stack) > 0 {
+			return stack[len(stack)-1]
+		}
+		return nil
+	}
+	rootDef := ""
+	blockCol := -1
+	for i, line := range strings.Split(string(def), "\n") {
+		text := strings.TrimRight(line, " \t\r")
+		rest := strings.TrimLeft(text, " ")
+		col := len(text) - len(rest)
+		if blockCol >= 0 {
+			if rest == "" || col > blockCol {
+				continue
+			}
+			blockCol = -1
+		}
+		if rest == "" || rest[0] == '#' || rest == "---" {
+			continue
+		}
+		for rest == "-" || strings.HasPrefix(rest, "- ") {
+			for f := top(); f != nil && (f.indent > col || f.indent == col && f.item); f = top() {
+				stack = stack[:len(stack)-1]
+			}
+			item := &locFrame{indent: col, item: true, pos: defPos{i + 1, col + 1}, cmdDef: rootDef}
+			if parent := top(); parent != nil {
+				item.key = parent.key
+				item.path = parent.path + "[" + strconv.Itoa(parent.count) + "]"
+				item.cmdDef = parent.cmdDef
+				parent.count++
+			}
+			stack = append(stack, item)
+			trimmed := strings.TrimLeft(rest[1:], " ")
+			col += len(rest) - len(trimmed)
+			rest = trimmed
+		}
+		m := locKeyPattern.FindStringSubmatch(rest)
+		if m == nil {
+			continue
+		}
+		key := m[1] + m[2] + m[3]
+		val := strings.TrimSpace(m[4])
+		for f := top(); f != nil && f.indent >= col; f = top() {
+			stack = stack[:len(stack)-1]
+		}
+		parent := top()
+		frame := &locFrame{indent: col, key: key, path: key, pos: defPos{i + 1, col + 1}, cmdDef: rootDef}
+		if parent != nil {
+			frame.path = parent.path + "." + key
+			frame.cmdDef = parent.cmdDef
+		}
+		l.keys[frame.path] = frame.pos
+		stack = append(stack, frame)
+		if strings.HasPrefix(val, "|") || strings.HasPrefix(val, ">") {
+			blockCol = col
+		}
+		if key == "name" && val != "" {
+			l.nameFound(parent, unquote(val), frame.pos, &rootDef)
+		}
+	}
+	return l
+}
+
+// nameFound records the definition owning the name key
+func (l *defLocator) nameFound(owner *locFrame, name string, pos defPos, rootDef *string) {
+	switch {
+	case owner == nil:
+		// a command at top level
+		*rootDef = name
+		l.defs[name] = pos
+	case !owner.item && owner.key == "cli" && !strings.Contains(owner.path, "."):
+		owner.cmdDef = name
+		l.defs[name] = pos
+	case owner.item && owner.key == "commands":
+		owner.cmdDef += "/" + name
+		l.defs[owner.cmdDef] = owner.pos
+	case owner.item && (owner.key == "options" || owner.key == "arguments"):
+		l.defs[owner.cmdDef+"["+name+"]"] = owner.pos
+	}
+}
+
+func unquote(val string) string {
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
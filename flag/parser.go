@@ -1,8 +1,11 @@
 package flag
 
 import (
+	"fmt"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +16,23 @@ const (
 	VarErrNoVal = 1
 	// VarErrBadVal means the value is invalid (e.g. unable to parse)
 	VarErrBadVal = 2
+	// VarErrExclusive means the option is used with Peers exclusive to it
+	VarErrExclusive = 3
+	// VarErrRequires means the option is used without required Peers
+	VarErrRequires = 4
+	// VarErrAtLeastOne means none of the option and Peers is used
+	VarErrAtLeastOne = 5
+	// VarErrAmbiguous means the prefix matches all the options in Peers
+	VarErrAmbiguous = 6
+
+	// VarSrcArgs means the value is from command line (or Assign)
+	VarSrcArgs = "args"
+	// VarSrcEnv means the value is from an environment variable
+	VarSrcEnv = "env"
+	// VarSrcConfig means the value is from a configuration file
+	VarSrcConfig = "config"
+	// VarSrcExt means the value is set by an extension
+	VarSrcExt = "ext"
 
 	statePre    = "p"
 	stateCmd    = "c"
@@ -28,6 +48,13 @@ type VarError struct {
 	Value   *string
 	Def     *Option
 	ErrType int
+	// File and Line locate the value when it's not from command line
+	File string
+	Line int
+	// Peers are the other options involved in constraint errors
+	Peers []string
+	// Reason explains the error in detail, e.g. why the value is invalid
+	Reason string
 }
 
 // ParsedCmd represent a Command which is being parsed or parsed in stack
@@ -37,7 +64,16 @@ type ParsedCmd struct {
 	ParsedArgC int
 	Vars       map[string]interface{}
 	Opts       map[string]string
-	Errs       []*VarError
+	// Envs records the raw values taken from environment variables
+	Envs map[string]string
+	// Sources records where the values come from (VarSrcXXX),
+	// the values not in it are defaults
+	Sources map[string]string
+	Errs    []*VarError
+
+	// defaultErrs are the errors of computed default values, reported
+	// if the options are not given values
+	defaultErrs []*VarError
 }
 
 // ParseResult represent the result of parsing process
@@ -63,8 +99,21 @@ type Parser struct {
 	option   *Option
 	optName  string
 	stackPos int
+	// optLong indicates the option is a long one, whose value can't
+	// start with "-" when separated by space
+	optLong bool
 
 	pushBack []string
+	// pushed is the number of pushed back args not parsed yet,
+	// including the one being parsed
+	pushed int
+
+	// strictLongVal only accepts --flag=VALUE for non-bool long options
+	strictLongVal bool
+	// respFiles expands @file into the arguments read from the file
+	respFiles bool
+	// prefixMatch accepts unique prefixes of long options and subcommands
+	prefixMatch bool
 
 	// extensions
 	exts map[string][]ParseExt
@@ -74,10 +123,35 @@ func newParsedCmd(cmd *Command) *ParsedCmd {
 	pcmd := &ParsedCmd{Cmd: cmd}
 	pcmd.Vars = make(map[string]interface{})
 	pcmd.Opts = make(map[string]string)
-	cmd.DefaultVars(pcmd.Vars)
+	pcmd.Envs = make(map[string]string)
+	pcmd.Sources = make(map[string]string)
+	pcmd.defaultErrs = cmd.DefaultVars(pcmd.Vars)
+	pcmd.assignEnvs(cmd.Options)
+	pcmd.assignEnvs(cmd.Arguments)
 	return pcmd
 }
 
+// assignEnvs overrides default values with environment variables,
+// the values from command line are assigned later and always win
+func (pcmd *ParsedCmd) assignEnvs(opts []*Option) {
+	for _, opt := range opts {
+		if opt.Env == "" {
+			continue
+		}
+		val := os.Getenv(opt.Env)
+		if val == "" {
+			continue
+		}
+		if parsedVal, err := opt.ParseEnvVal(val); err != nil {
+			pcmd.varBadVal(opt, &val, err)
+		} else {
+			pcmd.Vars[opt.Name] = parsedVal
+			pcmd.Envs[opt.Name] = val
+			pcmd.Sources[opt.Name] = VarSrcEnv
+		}
+	}
+}
+
 func (pcmd *ParsedCmd) varError(err *VarError) {
 	for _, e := range pcmd.Errs {
 		if e.Name == err.Name &&
@@ -97,8 +171,20 @@ func (pcmd *ParsedCmd) varNoVal(name string, opt *Option) {
 	pcmd.varError(&VarError{Name: name, Def: opt, ErrType: VarErrNoVal})
 }
 
-func (pcmd *ParsedCmd) varBadVal(opt *Option, val *string) {
-	pcmd.varError(&VarError{Name: opt.Name, Def: opt, Value: val, ErrType: VarErrBadVal})
+func (pcmd *ParsedCmd) varBadVal(opt *Option, val *string, err error) {
+	varErr := &VarError{Name: opt.Name, Def: opt, Value: val, ErrType: VarErrBadVal}
+	if valErr, ok := err.(*ValueError); ok {
+		varErr.Reason = valErr.Reason
+	}
+	pcmd.varError(varErr)
+}
+
+func (pcmd *ParsedCmd) verifyDefaults() {
+	for _, err := range pcmd.defaultErrs {
+		if _, given := pcmd.Sources[err.Name]; !given {
+			pcmd.varError(err)
+		}
+	}
 }
 
 func (pcmd *ParsedCmd) verifyRequiredOpts() {
@@ -112,12 +198,112 @@ func (pcmd *ParsedCmd) verifyRequiredOpts() {
 	}
 }
 
+// isSet tells whether an option is given a value (not the default),
+// even if it's the same as the default, and a bool option is only set
+// when it's true
+func (pcmd *ParsedCmd) isSet(name string) bool {
+	opt := pcmd.Cmd.FindOption(name)
+	if opt == nil {
+		return false
+	}
+	if _, given := pcmd.Sources[opt.Name]; !given {
+		return false
+	}
+	if b, ok := pcmd.Vars[opt.Name].(bool); ok {
+		return b
+	}
+	return true
+}
+
+func (pcmd *ParsedCmd) varConstraint(opt *Option, errType int, peers []string) {
+	pcmd.varError(&VarError{Name: opt.Name, Def: opt, ErrType: errType, Peers: peers})
+}
+
+func (pcmd *ParsedCmd) verifyConstraints() {
+	cmd := pcmd.Cmd
+	for _, group := range cmd.Exclusive {
+		var used []string
+		for _, name := range group {
+			if pcmd.isSet(name) {
+				used = append(used, cmd.FindOption(name).Name)
+			}
+		}
+		if len(used) > 1 {
+			pcmd.varConstraint(cmd.FindOption(used[0]), VarErrExclusive, used[1:])
+		}
+	}
+	names := make([]string, 0, len(cmd.Requires))
+	for name := range cmd.Requires {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !pcmd.isSet(name) {
+			continue
+		}
+		var missing []string
+		for _, req := range cmd.Requires[name] {
+			if !pcmd.isSet(req) {
+				missing = append(missing, cmd.FindOption(req).Name)
+			}
+		}
+		if len(missing) > 0 {
+			pcmd.varConstraint(cmd.FindOption(name), VarErrRequires, missing)
+		}
+	}
+	for _, group := range cmd.AtLeastOne {
+		found := false
+		for _, name := range group {
+			if pcmd.isSet(name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			opt := cmd.FindOption(group[0])
+			var peers []string
+			for _, name := range group[1:] {
+				peers = append(peers, cmd.FindOption(name).Name)
+			}
+			pcmd.varConstraint(opt, VarErrAtLeastOne, peers)
+		}
+	}
+}
+
+// listArg returns the last argument if it's a list
+func (pcmd *ParsedCmd) listArg() *Option {
+	if l := len(pcmd.Cmd.Arguments); l > 0 && pcmd.Cmd.Arguments[l-1].List {
+		return pcmd.Cmd.Arguments[l-1]
+	}
+	return nil
+}
+
+// nextArg returns the argument which takes the next positional value
+func (pcmd *ParsedCmd) nextArg() *Option {
+	if at := len(pcmd.Args); at < len(pcmd.Cmd.Arguments) {
+		return pcmd.Cmd.Arguments[at]
+	}
+	return pcmd.listArg()
+}
+
+// argsFilled tells whether all declared arguments except a list one have values
+func (pcmd *ParsedCmd) argsFilled() bool {
+	arg := pcmd.nextArg()
+	return arg == nil || arg.List
+}
+
 func (pcmd *ParsedCmd) verifyRequiredArgs() {
 	for i, arg := range pcmd.Cmd.Arguments {
+		if arg.List {
+			pcmd.verifyListArg(arg)
+			break
+		}
 		if i < len(pcmd.Args) {
 			continue
 		}
-		if arg.Required {
+		if val, exists := pcmd.Envs[arg.Name]; exists {
+			pcmd.Args = append(pcmd.Args, val)
+		} else if arg.Required {
 			pcmd.varNoVal(arg.Name, arg)
 			pcmd.Args = append(pcmd.Args, "")
 		} else {
@@ -126,20 +312,59 @@ func (pcmd *ParsedCmd) verifyRequiredArgs() {
 	}
 }
 
+// verifyListArg checks the number of values of a list argument,
+// the values may come from command line, environment or default
+func (pcmd *ParsedCmd) verifyListArg(arg *Option) {
+	list, _ := pcmd.Vars[arg.Name].([]interface{})
+	if len(pcmd.Args) < arg.Position {
+		for _, val := range list {
+			pcmd.Args = append(pcmd.Args, fmt.Sprintf("%v", val))
+		}
+	}
+	minCount := arg.MinCount
+	if arg.Required && minCount < 1 {
+		minCount = 1
+	}
+	if len(list) == 0 && arg.Required {
+		pcmd.varNoVal(arg.Name, arg)
+	} else if len(list) < minCount {
+		pcmd.varError(&VarError{
+			Name:    arg.Name,
+			Def:     arg,
+			ErrType: VarErrNoVal,
+			Reason:  fmt.Sprintf("expect at least %d values", minCount),
+		})
+	}
+}
+
 func (pcmd *ParsedCmd) hasSubCommands() bool {
 	return len(pcmd.Cmd.Commands) > 0
 }
 
-func (pcmd *ParsedCmd) startSubCommand(name string) *ParsedCmd {
+// startSubCommand starts the subcommand by name, or a unique prefix if
+// prefix is true, and returns the candidates if the prefix is ambiguous
+func (pcmd *ParsedCmd) startSubCommand(name string, prefix bool) (*ParsedCmd, []string) {
 	if cmd := pcmd.Cmd.FindCommand(name); cmd != nil {
-		return newParsedCmd(cmd)
+		return newParsedCmd(cmd), nil
 	}
-	return nil
+	if !prefix {
+		return nil, nil
+	}
+	found := pcmd.Cmd.MatchCommands(name)
+	var candidates []string
+	for cmd, matched := range found {
+		if len(found) == 1 {
+			return newParsedCmd(cmd), nil
+		}
+		candidates = append(candidates, matched)
+	}
+	sort.Strings(candidates)
+	return nil, candidates
 }
 
 func (pcmd *ParsedCmd) assignOption(opt *Option, val string, valNot bool) (parsedVal interface{}, err error) {
 	if parsedVal, err = opt.ParseStrVal(val); err != nil {
-		pcmd.varBadVal(opt, &val)
+		pcmd.varBadVal(opt, &val, err)
 		return
 	} else if opt.ValueKind == reflect.Map {
 		var destMap map[string]interface{}
@@ -151,10 +376,7 @@ func (pcmd *ParsedCmd) assignOption(opt *Option, val string, valNot bool) (parse
 		if destMap == nil {
 			destMap = make(map[string]interface{})
 		}
-		parsedMap := parsedVal.(map[string]interface{})
-		for k, v := range parsedMap {
-			destMap[k] = v
-		}
+		mergeDict(destMap, parsedVal.(map[string]interface{}))
 		parsedVal = destMap
 	} else {
 		if opt.ValueKind == reflect.Bool && valNot {
@@ -175,6 +397,7 @@ func (pcmd *ParsedCmd) assignOption(opt *Option, val string, valNot bool) (parse
 	}
 	pcmd.Vars[opt.Name] = parsedVal
 	pcmd.Opts[opt.Name] = val
+	pcmd.Sources[opt.Name] = VarSrcArgs
 	return
 }
 
@@ -197,15 +420,44 @@ func (p *Parser) startParsing(program string) {
 	p.pushCommand(newParsedCmd(p.rootCmd))
 }
 
+// findOption finds an option from the innermost command, and the local
+// options of parent commands are skipped
 func (p *Parser) findOption(name string) (*Option, int) {
-	for i := len(p.result.CmdStack) - 1; i >= 0; i-- {
-		if opt := p.result.CmdStack[i].Cmd.FindOption(name); opt != nil {
+	top := len(p.result.CmdStack) - 1
+	for i := top; i >= 0; i-- {
+		if opt := p.result.CmdStack[i].Cmd.FindOption(name); opt != nil && (i == top || !opt.Local) {
 			return opt, i
 		}
 	}
 	return nil, -1
 }
 
+// findLongOption finds an option by long name, or a unique prefix in
+// prefix matching mode, and returns the candidates if ambiguous
+func (p *Parser) findLongOption(name string) (*Option, int, []string) {
+	if opt, at := p.findOption(name); opt != nil || !p.prefixMatch {
+		return opt, at, nil
+	}
+	var found *Option
+	var foundAt int
+	var candidates []string
+	top := len(p.result.CmdStack) - 1
+	for i := top; i >= 0; i-- {
+		for opt, matched := range p.result.CmdStack[i].Cmd.MatchOptions(name) {
+			if i < top && opt.Local {
+				continue
+			}
+			found, foundAt = opt, i
+			candidates = append(candidates, matched)
+		}
+	}
+	if len(candidates) == 1 {
+		return found, foundAt, nil
+	}
+	sort.Strings(candidates)
+	return nil, -1, candidates
+}
+
 func (p *Parser) stackAt(at int) *ParsedCmd {
 	return p.result.CmdStack[at]
 }
@@ -252,8 +504,9 @@ func (p *Parser) assignOption(at int, opt *Option, val string, valNot bool) {
 		Not:      valNot,
 	}
 	p.invokeExts(EvtAssignOpt, ctx)
-	if ctx.Value != nil {
-		if val, err := p.stackAt(at).assignOption(opt, *ctx.Value, ctx.Not); err == nil {
+	// the extensions may redirect the value to another option
+	if ctx.Value != nil && ctx.Option != nil && ctx.CmdAt(ctx.OptionAt) != nil {
+		if val, err := p.stackAt(ctx.OptionAt).assignOption(ctx.Option, *ctx.Value, ctx.Not); err == nil {
 			ctx.Assigned = val
 			p.invokeExts(EvtAssigned, ctx)
 		}
@@ -268,8 +521,26 @@ func (p *Parser) pushArg(arg string) {
 		at := len(pcmd.Args)
 		pcmd.Args = append(pcmd.Args, arg)
 		if at < len(pcmd.Cmd.Arguments) {
+			def := pcmd.Cmd.Arguments[at]
+			if def.List {
+				// values from command line replace the default list
+				delete(pcmd.Vars, def.Name)
+			}
 			pcmd.ParsedArgC++
-			p.assignOption(len(p.result.CmdStack)-1, pcmd.Cmd.Arguments[at], arg, false)
+			p.assignOption(len(p.result.CmdStack)-1, def, arg, false)
+		} else if def := pcmd.listArg(); def != nil {
+			if count := at - def.Position + 2; def.MaxCount > 0 && count > def.MaxCount {
+				pcmd.varError(&VarError{
+					Name:    def.Name,
+					Def:     def,
+					Value:   &arg,
+					ErrType: VarErrBadVal,
+					Reason:  fmt.Sprintf("expect at most %d values", def.MaxCount),
+				})
+			} else {
+				pcmd.ParsedArgC++
+				p.assignOption(len(p.result.CmdStack)-1, def, arg, false)
+			}
 		}
 	}
 }
@@ -297,16 +568,67 @@ func (p *Parser) parseArg(arg string) {
 	p.invokeExts(EvtParseArg, ctx)
 	if !ctx.Ignore {
 		if p.currCmd.hasSubCommands() {
-			pcmd := p.currCmd.startSubCommand(arg)
+			pcmd, candidates := p.currCmd.startSubCommand(arg, p.prefixMatch)
 			if pcmd != nil {
 				p.pushCommand(pcmd)
+			} else if len(candidates) > 0 {
+				p.result.Error = &AmbiguousCmdError{Name: arg, Candidates: candidates}
+				p.result.UnparsedArgs = []string{arg}
+				p.state = stateErr
 			} else {
 				p.resolveUnknownCommand(arg)
 			}
+		} else if p.currCmd.Cmd.StopAtArgs && p.currCmd.argsFilled() {
+			p.state = stateEnd
+			p.parseOne(arg)
 		} else {
 			p.pushArg(arg)
+			if p.currCmd.Cmd.StopAtArgs && p.currCmd.argsFilled() {
+				// the remaining args are passed through
+				p.state = stateEnd
+			}
+		}
+	}
+}
+
+// numberValue tells whether a dash-leading arg is a negative number taken
+// by the numeric option or argument, rather than a short option
+func (p *Parser) numberValue(opt *Option, arg string) bool {
+	if opt == nil || opt.vtype != nil ||
+		opt.ValueKind != reflect.Int64 && opt.ValueKind != reflect.Float64 {
+		return false
+	}
+	if len(arg) < 2 || (arg[1] < '0' || arg[1] > '9') && arg[1] != '.' {
+		return false
+	}
+	if _, err := strconv.ParseFloat(arg, 64); err != nil {
+		if _, err = strconv.ParseInt(arg, 0, 64); err != nil {
+			return false
 		}
 	}
+	opt, _ = p.findOption(arg[1:2])
+	return opt == nil
+}
+
+// flagValue gives the value of an option used without a value,
+// a counting option is increased, or reset to 0 by --no-flag
+func (p *Parser) flagValue(at int, opt *Option, valNot bool) string {
+	if !opt.IsCount {
+		return "true"
+	} else if valNot {
+		return "0"
+	}
+	count, _ := p.stackAt(at).Vars[opt.Name].(int64)
+	return strconv.FormatInt(count+1, 10)
+}
+
+// expectValue saves the option and waits for the value in next arg
+func (p *Parser) expectValue(name string, opt *Option, at int, long bool) {
+	p.optName = name
+	p.option = opt
+	p.stackPos = at
+	p.optLong = long
+	p.state = stateVal
 }
 
 func (p *Parser) parseOne(arg string) {
@@ -328,33 +650,42 @@ func (p *Parser) parseOne(arg string) {
 				name = name[0:pos]
 			}
 
-			opt, at := p.findOption(name)
+			opt, at, candidates := p.findLongOption(name)
 			valNot := false
 			if opt == nil {
-				// if prefixed with "--no-", try to find a bool option
-				if strings.HasPrefix(arg, "--no-") {
-					name = name[3:]
-					opt, at = p.findOption(name)
-					if opt != nil && opt.ValueKind == reflect.Bool {
-						valNot = true
-					} else {
-						name = arg[2:]
-						opt = nil
+				// if prefixed with "--no-", try to find a bool or counting option
+				if strings.HasPrefix(name, "no-") {
+					if notOpt, notAt, _ := p.findLongOption(name[3:]); notOpt != nil && !notOpt.ExpectValue() {
+						opt, at, valNot = notOpt, notAt, true
+						name = name[3:]
 					}
 				}
 			}
-			if opt == nil {
+			if opt != nil && p.prefixMatch && p.stackAt(at).Cmd.FindOption(name) != opt {
+				// matched by prefix
+				name = opt.Name
+			}
+			if opt == nil && len(candidates) > 0 {
+				p.currCmd.varError(&VarError{Name: name, ErrType: VarErrAmbiguous, Peers: candidates})
+			} else if opt == nil {
 				p.resolveUnknownOption(name, val)
 			} else if val != nil {
 				// option with a value --flag=VALUE
 				p.assignOption(at, opt, *val, valNot)
-			} else if opt.ValueKind == reflect.Bool {
+			} else if !opt.ExpectValue() {
 				// bool option without a value --flag or --no-flag (valNot=true)
-				p.assignOption(at, opt, "true", valNot)
-			} else {
-				// non-bool long option always require a value --flag=VALUE
+				p.assignOption(at, opt, p.flagValue(at, opt, valNot), valNot)
+			} else if p.strictLongVal {
+				// in strict mode, non-bool long option require --flag=VALUE
 				p.stackAt(at).varNoVal(name, opt)
+			} else {
+				// for non-bool, --flag VALUE is expected
+				p.expectValue(name, opt, at, true)
 			}
+		} else if strings.HasPrefix(arg, "-") && !p.currCmd.hasSubCommands() &&
+			p.numberValue(p.currCmd.nextArg(), arg) {
+			// negative number as a positional argument
+			p.parseArg(arg)
 		} else if strings.HasPrefix(arg, "-") {
 			for i, nameRune := range arg[1:] {
 				name := string(nameRune)
@@ -366,27 +697,31 @@ func (p *Parser) parseOne(arg string) {
 				opt, at := p.findOption(name)
 				if opt == nil {
 					p.resolveUnknownOption(name, val)
-				} else if opt.ValueKind == reflect.Bool {
-					// for bool, -f indicate true
-					p.assignOption(at, opt, "true", false)
+				} else if !opt.ExpectValue() {
+					// for bool, -f indicate true, and -fff counts 3
+					p.assignOption(at, opt, p.flagValue(at, opt, false), false)
 				} else if val != nil {
 					// for non-bool, -fVALUE, the rest is value
 					p.assignOption(at, opt, *val, false)
 					break
 				} else {
 					// for non-bool, -f VALUE is expected
-					p.optName = name
-					p.option = opt
-					p.stackPos = at
-					p.state = stateVal
+					p.expectValue(name, opt, at, false)
 				}
 			}
 		} else {
 			p.parseArg(arg)
 		}
 	case stateVal:
-		p.assignOption(p.stackPos, p.option, arg, false)
 		p.state = stateCmd
+		if p.optLong && len(arg) > 1 && arg[0] == '-' &&
+			!p.option.AllowDashValue && !p.numberValue(p.option, arg) {
+			// --flag followed by another option, the value is missing
+			p.stackAt(p.stackPos).varNoVal(p.optName, p.option)
+			p.parseOne(arg)
+		} else {
+			p.assignOption(p.stackPos, p.option, arg, false)
+		}
 	case stateEnd:
 		p.pushArg(arg)
 		p.result.UnparsedArgs = append(p.result.UnparsedArgs, arg)
@@ -403,9 +738,23 @@ func (p *Parser) parseOne(arg string) {
 
 func (p *Parser) parse(arg string) {
 	args := []string{arg}
+	if p.respFiles && p.state == stateCmd && len(arg) > 1 && arg[0] == '@' {
+		expanded, err := ExpandResponseFile(arg[1:])
+		if err != nil {
+			p.result.Error = err
+			p.state = stateErrEnd
+			return
+		}
+		args = expanded
+	}
 	for len(args) > 0 {
 		p.parseOne(args[0])
-		args = append(args[1:], p.pushBack...)
+		if p.pushed > 0 {
+			p.pushed--
+		}
+		// the args pushed back are parsed before the rest
+		p.pushed += len(p.pushBack)
+		args = append(p.pushBack, args[1:]...)
 		p.pushBack = nil
 	}
 }
@@ -419,7 +768,9 @@ func (p *Parser) parseEnd() error {
 		p.stackAt(p.stackPos).varNoVal(p.optName, p.option)
 	}
 	for _, pcmd := range p.result.CmdStack {
+		pcmd.verifyDefaults()
 		pcmd.verifyRequiredOpts()
+		pcmd.verifyConstraints()
 	}
 	if p.state == stateCmd || p.state == stateEnd {
 		p.currCmd.verifyRequiredArgs()
@@ -444,6 +795,29 @@ func (p *Parser) Parse() *ParseResult {
 	return p.ParseArgs(os.Args...)
 }
 
+// StrictLongValue requires the value of a non-bool long option to be
+// specified as --flag=VALUE, and --flag VALUE is rejected
+func (p *Parser) StrictLongValue(strict bool) *Parser {
+	p.strictLongVal = strict
+	return p
+}
+
+// ResponseFiles enables expanding an argument @path into the arguments
+// read from the file, see ExpandResponseFile. Arguments after "--" and
+// values of options are never expanded.
+func (p *Parser) ResponseFiles(enable bool) *Parser {
+	p.respFiles = enable
+	return p
+}
+
+// PrefixMatch enables matching long options and subcommands (including
+// aliases) by unique prefixes, e.g. --verb for --verbose, the exact names
+// always win
+func (p *Parser) PrefixMatch(enable bool) *Parser {
+	p.prefixMatch = enable
+	return p
+}
+
 // Use registers an extension to current parser
 func (p *Parser) Use(extRegs ...ExtRegistrar) *Parser {
 	for _, ext := range extRegs {
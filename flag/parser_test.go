@@ -2,8 +2,12 @@ package flag
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -102,6 +106,15 @@ func TestLongFlags(t *testing.T) {
 		a.True(cs.Vars["wait"].(bool))
 		a.Equal("VAL", cs.Vars["flag"].(string))
 	}
+	r = cli.ParseArgs("cli", "--server", "123", "down", "--flag", "VAL", "a1")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.MissingCmd) {
+		a.False(r.HasErrors())
+		a.Equal("123", r.CmdStack[0].Vars["server"].(string))
+		cs := r.CmdStack[1]
+		a.Equal("down", cs.Cmd.Name)
+		a.Equal([]string{"a1"}, cs.Args)
+		a.Equal("VAL", cs.Vars["flag"].(string))
+	}
 	r = cli.ParseArgs("cli", "down", "-f", "VAL", "--no-wait")
 	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.MissingCmd) {
 		a.False(r.HasErrors())
@@ -119,12 +132,12 @@ func TestDefaults(t *testing.T) {
 		a.False(r.HasErrors())
 		cs := r.CmdStack[1]
 		a.Equal("defs", cs.Cmd.Name)
-		a.Len(cs.Args, 5)
+		a.Len(cs.Args, 4)
 		a.Equal("", cs.Vars["str"])
 		a.Equal(int64(0), cs.Vars["int"])
 		a.Equal(float64(0), cs.Vars["num"])
 		a.Equal(map[string]interface{}{}, cs.Vars["dict"])
-		a.Equal("", cs.Vars["slice"]) // args should never be list
+		a.Equal([]interface{}{}, cs.Vars["slice"])
 	}
 	r = cli.ParseArgs("cli", "d")
 	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.MissingCmd) {
@@ -193,6 +206,54 @@ func TestMapOptions(t *testing.T) {
 	}
 }
 
+func TestTypedMapOptions(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "map", "--no-defs=x",
+		"--ports=http=80", "--ports=web.https=0x1bb",
+		"--flags=debug", "--flags=trace=false",
+		"--timeouts=read=5s",
+		"--conf=db.port=6543", "--conf=db.name=test", "--conf=log.level=debug")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.HasErrors()) {
+		cs := r.CmdStack[1]
+		a.Equal(map[string]interface{}{
+			"http": int64(80),
+			"web":  map[string]interface{}{"https": int64(443)},
+		}, cs.Vars["ports"])
+		a.Equal(map[string]interface{}{"debug": true, "trace": false}, cs.Vars["flags"])
+		a.Equal(map[string]interface{}{"read": 5 * time.Second}, cs.Vars["timeouts"])
+		a.Equal(map[string]interface{}{
+			"db":  map[string]interface{}{"host": "localhost", "port": "6543", "name": "test"},
+			"log": map[string]interface{}{"level": "debug"},
+		}, cs.Vars["conf"])
+		a.Equal(map[string]interface{}{
+			"db": map[string]interface{}{"host": "localhost", "port": 5432},
+		}, cs.Cmd.DefVars["conf"])
+	}
+
+	// dotted keys are kept without nested-keys
+	r = cli.ParseArgs("cli", "map", "--no-defs=x", "--labels=a.b=1", "--labels=.c")
+	if a.NoError(r.Error) && a.False(r.HasErrors()) {
+		a.Equal(map[string]interface{}{"a.b": "1", ".c": true}, r.CmdStack[1].Vars["labels"])
+	}
+
+	for _, arg := range []string{"--ports=http=x", "--ports=http", "--conf=a..b=1", "--conf=.a"} {
+		r = cli.ParseArgs("cli", "map", "--no-defs=x", arg)
+		if a.NoError(r.Error) && a.True(r.HasErrors(), arg) {
+			a.Equal(VarErrBadVal, r.CmdStack[1].Errs[0].ErrType, arg)
+		}
+	}
+
+	os.Setenv("CLIX_TEST_PORTS", "http=80,web.https=443")
+	defer os.Unsetenv("CLIX_TEST_PORTS")
+	r = cli.ParseArgs("cli", "map", "--no-defs=x")
+	if a.NoError(r.Error) && a.False(r.HasErrors()) {
+		a.Equal(map[string]interface{}{
+			"http": int64(80),
+			"web":  map[string]interface{}{"https": int64(443)},
+		}, r.CmdStack[1].Vars["ports"])
+	}
+}
+
 func TestMissingRequired(t *testing.T) {
 	a := assert.New(t)
 	// required opt
@@ -222,7 +283,7 @@ func TestMissingRequired(t *testing.T) {
 func TestMissingValue(t *testing.T) {
 	a := assert.New(t)
 	// missing long flag value
-	r := cli.ParseArgs("cli", "reqs", "--req1", "a")
+	r := cli.ParseArgs("cli", "reqs", "--req1")
 	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.MissingCmd) {
 		a.True(r.HasErrors())
 		cs := r.CmdStack[1]
@@ -232,6 +293,28 @@ func TestMissingValue(t *testing.T) {
 			a.Nil(cs.Errs[0].Value)
 		}
 	}
+	// long flag followed by another option
+	r = cli.ParseArgs("cli", "down", "--flag", "-w")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.MissingCmd) {
+		cs := r.CmdStack[1]
+		if a.Len(cs.Errs, 1) {
+			a.Equal("flag", cs.Errs[0].Name)
+			a.Equal(VarErrNoVal, cs.Errs[0].ErrType)
+		}
+		a.True(cs.Vars["wait"].(bool))
+	}
+	// long flag value must come with "=" in strict mode
+	r = cli.Parser().StrictLongValue(true).ParseArgs("cli", "reqs", "--req1", "a")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.MissingCmd) {
+		a.True(r.HasErrors())
+		cs := r.CmdStack[1]
+		if a.Len(cs.Errs, 1) {
+			a.Equal("req1", cs.Errs[0].Name)
+			a.Equal(VarErrNoVal, cs.Errs[0].ErrType)
+			a.Nil(cs.Errs[0].Value)
+		}
+		a.Equal([]string{"a"}, cs.Args)
+	}
 	// missing short flag value
 	r = cli.ParseArgs("cli", "down", "-f")
 	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.MissingCmd) {
@@ -700,3 +783,439 @@ func TestExecDoneExt(t *testing.T) {
 		a.Equal("done", err.Error())
 	}
 }
+
+func TestEnvOptions(t *testing.T) {
+	a := assert.New(t)
+	envs := map[string]string{
+		"CLIX_TEST_STR":  "env",
+		"CLIX_TEST_INT":  "10",
+		"CLIX_TEST_BOOL": "true",
+		"CLIX_TEST_LIST": "1,2",
+		"CLIX_TEST_DICT": "a=1,b",
+		"CLIX_TEST_REQ":  "req",
+		"CLIX_TEST_ARG":  "arg",
+	}
+	for k, v := range envs {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	r := cli.ParseArgs("cli", "envs", "--int=20")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) && a.False(r.MissingCmd) {
+		a.False(r.HasErrors())
+		cs := r.CmdStack[1]
+		a.Equal("env", cs.Vars["str"])
+		a.Equal(int64(20), cs.Vars["int"])
+		a.Equal(true, cs.Vars["bool"])
+		a.Equal([]interface{}{int64(1), int64(2)}, cs.Vars["list"])
+		a.Equal(map[string]interface{}{"a": "1", "b": true}, cs.Vars["dict"])
+		a.Equal("req", cs.Vars["req"])
+		a.Equal("arg", cs.Vars["arg"])
+		a.Equal([]string{"arg"}, cs.Args)
+		a.Equal("env", cs.Envs["str"])
+		a.Equal("10", cs.Envs["int"])
+		a.Equal("20", cs.Opts["int"])
+		a.NotContains(cs.Opts, "str")
+	}
+
+	os.Setenv("CLIX_TEST_INT", "not-int")
+	r = cli.ParseArgs("cli", "envs")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.True(r.HasErrors())
+		cs := r.CmdStack[1]
+		if a.Len(cs.Errs, 1) {
+			a.Equal("int", cs.Errs[0].Name)
+			a.Equal(VarErrBadVal, cs.Errs[0].ErrType)
+			a.Equal("not-int", *cs.Errs[0].Value)
+		}
+	}
+
+	os.Unsetenv("CLIX_TEST_INT")
+	os.Unsetenv("CLIX_TEST_STR")
+	os.Unsetenv("CLIX_TEST_REQ")
+	r = cli.ParseArgs("cli", "envs")
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal("def", cs.Vars["str"])
+		a.Equal(int64(0), cs.Vars["int"])
+		if a.Len(cs.Errs, 1) {
+			a.Equal("req", cs.Errs[0].Name)
+			a.Equal(VarErrNoVal, cs.Errs[0].ErrType)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	a := assert.New(t)
+	pt := cli.Parser().Complete("cli", "o")
+	a.Equal(CompleteCmd, pt.Kind)
+	a.Equal("o", pt.Prefix)
+	a.Len(pt.CmdStack, 1)
+
+	pt = cli.Parser().Complete("cli", "up", "--fl")
+	a.Equal(CompleteOptName, pt.Kind)
+	a.Equal("up", pt.CurrentCmd().Cmd.Name)
+
+	pt = cli.Parser().Complete("cli", "down", "-f", "x")
+	if a.Equal(CompleteOptVal, pt.Kind) && a.NotNil(pt.Option) {
+		a.Equal("flag", pt.Option.Name)
+		a.Equal(1, pt.OptionAt)
+		a.Equal("x", pt.Prefix)
+	}
+
+	pt = cli.Parser().Complete("cli", "down", "--flag", "x")
+	if a.Equal(CompleteOptVal, pt.Kind) && a.NotNil(pt.Option) {
+		a.Equal("flag", pt.Option.Name)
+		a.Equal("x", pt.Prefix)
+	}
+
+	pt = cli.Parser().Complete("cli", "down", "--flag", "--w")
+	a.Equal(CompleteOptName, pt.Kind)
+
+	pt = cli.Parser().Complete("cli", "up", "--server=1")
+	if a.Equal(CompleteOptVal, pt.Kind) && a.NotNil(pt.Option) {
+		a.Equal("server", pt.Option.Name)
+		a.Equal(0, pt.OptionAt)
+		a.Equal("1", pt.Prefix)
+	}
+
+	pt = cli.Parser().Complete("cli", "objects", "create", "")
+	if a.Equal(CompleteArg, pt.Kind) && a.NotNil(pt.Option) {
+		a.Equal("type", pt.Option.Name)
+	}
+
+	pt = cli.Parser().Complete("cli", "objects", "create", "t", "--", "")
+	a.Equal(CompleteArg, pt.Kind)
+	a.Nil(pt.Option)
+
+	pt = cli.Parser().Complete("cli", "unknown", "")
+	a.Equal(CompleteNone, pt.Kind)
+}
+
+func TestConstraints(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "output", "--file=f")
+	a.False(r.HasErrors())
+
+	r = cli.ParseArgs("cli", "output", "--file=f", "--json", "--no-yaml")
+	a.False(r.HasErrors())
+
+	r = cli.ParseArgs("cli", "output", "--url=u", "--json", "-y")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		e := r.CmdStack[1].Errs[0]
+		a.Equal(VarErrExclusive, e.ErrType)
+		a.Equal("json", e.Name)
+		a.Equal([]string{"yaml"}, e.Peers)
+	}
+
+	r = cli.ParseArgs("cli", "output", "--url=u", "--key=k", "--ca=c")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		e := r.CmdStack[1].Errs[0]
+		a.Equal(VarErrRequires, e.ErrType)
+		a.Equal("key", e.Name)
+		a.Equal([]string{"cert"}, e.Peers)
+	}
+
+	r = cli.ParseArgs("cli", "output", "--json")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		e := r.CmdStack[1].Errs[0]
+		a.Equal(VarErrAtLeastOne, e.ErrType)
+		a.Equal("file", e.Name)
+		a.Equal([]string{"url"}, e.Peers)
+	}
+
+	// a value equal to the default is still given
+	r = cli.ParseArgs("cli", "output", "--file=out.txt")
+	a.False(r.HasErrors())
+	if a.Len(r.CmdStack, 2) {
+		a.Equal(VarSrcArgs, r.CmdStack[1].Sources["file"])
+	}
+}
+
+func TestValueRules(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "rules", "--format=yaml", "--level=1", "--level=5",
+		"--ratio=1", "--id=abc", "2")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal("yaml", cs.Vars["format"])
+		a.Equal([]interface{}{int64(1), int64(5)}, cs.Vars["level"])
+		a.Equal(int64(2), cs.Vars["mode"])
+	}
+
+	r = cli.ParseArgs("cli", "rules", "--format=xml", "--level=0", "--ratio=1.1",
+		"--id=a1", "3")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 5) {
+		for _, e := range r.CmdStack[1].Errs {
+			a.Equal(VarErrBadVal, e.ErrType)
+		}
+		errs := r.CmdStack[1].Errs
+		a.Equal("must be one of json, yaml", errs[0].Reason)
+		a.Equal("must be at least 1", errs[1].Reason)
+		a.Equal("must be at most 1", errs[2].Reason)
+		a.Equal("must match ^[a-z]+$", errs[3].Reason)
+		a.Equal("must be one of 1, 2", errs[4].Reason)
+	}
+
+	r = cli.ParseArgs("cli", "rules", "--level=x", "1")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		a.Empty(r.CmdStack[1].Errs[0].Reason)
+	}
+}
+
+func TestValueTypes(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "types")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal(30*time.Second, cs.Vars["timeout"])
+		a.Equal(time.Time{}, cs.Vars["since"])
+		a.Equal(int64(1024), cs.Vars["limit"])
+		a.Empty(cs.Vars["addr"])
+		a.Nil(cs.Vars["net"])
+		a.Nil(cs.Vars["endpoint"])
+	}
+
+	r = cli.ParseArgs("cli", "types", "--timeout=1m30s", "--since=2020-01-02",
+		"--limit=1.5G", "--addr=10.0.0.1", "--addr=::1", "--net=10.1.0.0/16",
+		"--endpoint=https://example.com/api")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal(90*time.Second, cs.Vars["timeout"])
+		a.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), cs.Vars["since"])
+		a.Equal(int64(1500000000), cs.Vars["limit"])
+		if addrs, ok := cs.Vars["addr"].([]interface{}); a.True(ok) && a.Len(addrs, 2) {
+			a.True(net.ParseIP("10.0.0.1").Equal(addrs[0].(net.IP)))
+			a.True(net.ParseIP("::1").Equal(addrs[1].(net.IP)))
+		}
+		if ipNet, ok := cs.Vars["net"].(*net.IPNet); a.True(ok) {
+			a.Equal("10.1.0.0/16", ipNet.String())
+		}
+		a.Equal("https://example.com/api", fmt.Sprintf("%v", cs.Vars["endpoint"]))
+	}
+
+	r = cli.ParseArgs("cli", "types", "--timeout=10", "--since=yesterday",
+		"--limit=10XB", "--addr=10.0.0", "--net=10.0.0.1", "--endpoint=/api")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 6) {
+		errs := r.CmdStack[1].Errs
+		a.Equal("expect duration like 1h30m or 10s", errs[0].Reason)
+		a.Equal("expect time like 2006-01-02T15:04:05Z or date like 2006-01-02", errs[1].Reason)
+		a.Equal("expect size like 512, 10MiB or 1.5G", errs[2].Reason)
+		a.Equal("expect IP address like 10.0.0.1 or ::1", errs[3].Reason)
+		a.Equal("expect CIDR like 10.0.0.0/8", errs[4].Reason)
+		a.Equal("expect absolute URL like https://host/path", errs[5].Reason)
+	}
+
+	r = cli.ParseArgs("cli", "types", "--timeout=10ms")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		a.Equal("must be at least 1s", r.CmdStack[1].Errs[0].Reason)
+	}
+}
+
+func TestListArgs(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "args", "a", "1", "2", "3")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal([]string{"a", "1", "2", "3"}, cs.Args)
+		a.Equal(4, cs.ParsedArgC)
+		a.Equal("a", cs.Vars["name"])
+		a.Equal([]interface{}{int64(1), int64(2), int64(3)}, cs.Vars["nums"])
+	}
+
+	r = cli.ParseArgs("cli", "args", "a")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal([]string{"a", "7", "8"}, cs.Args)
+		a.Equal([]interface{}{int64(7), int64(8)}, cs.Vars["nums"])
+	}
+
+	r = cli.ParseArgs("cli", "args", "a", "1")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		e := r.CmdStack[1].Errs[0]
+		a.Equal(VarErrNoVal, e.ErrType)
+		a.Equal("nums", e.Name)
+		a.Equal("expect at least 2 values", e.Reason)
+	}
+
+	r = cli.ParseArgs("cli", "args", "a", "1", "2", "3", "4", "x")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		cs := r.CmdStack[1]
+		e := cs.Errs[0]
+		a.Equal(VarErrBadVal, e.ErrType)
+		a.Equal("nums", e.Name)
+		a.Equal("4", *e.Value)
+		a.Equal("expect at most 3 values", e.Reason)
+		a.Len(cs.Args, 6)
+		a.Equal([]interface{}{int64(1), int64(2), int64(3)}, cs.Vars["nums"])
+	}
+
+	r = cli.ParseArgs("cli", "objects", "delete")
+	if a.Len(r.CmdStack, 3) && a.Len(r.CmdStack[2].Errs, 1) {
+		e := r.CmdStack[2].Errs[0]
+		a.Equal(VarErrNoVal, e.ErrType)
+		a.Empty(e.Reason)
+	}
+
+	pt := cli.Parser().Complete("cli", "args", "a", "1", "")
+	if a.Equal(CompleteArg, pt.Kind) && a.NotNil(pt.Option) {
+		a.Equal("nums", pt.Option.Name)
+	}
+}
+
+func TestPrefixMatch(t *testing.T) {
+	a := assert.New(t)
+	r := cli.Parser().PrefixMatch(true).ParseArgs("cli", "--ser=s1", "do", "--fl", "f1", "--no-wa")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal("s1", r.CmdStack[0].Vars["server"])
+		cs := r.CmdStack[1]
+		a.Equal("down", cs.Cmd.Name)
+		a.Equal("f1", cs.Vars["flag"])
+		a.False(cs.Vars["wait"].(bool))
+		a.Equal("f1", cs.Opts["flag"])
+	}
+
+	// exact name always wins
+	r = cli.Parser().PrefixMatch(true).ParseArgs("cli", "d")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal("defs", r.CmdStack[1].Cmd.Name)
+	}
+
+	r = cli.Parser().PrefixMatch(true).ParseArgs("cli", "up", "--fla", "a1")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		e := r.CmdStack[1].Errs[0]
+		a.Equal(VarErrAmbiguous, e.ErrType)
+		a.Equal("fla", e.Name)
+		a.Equal([]string{"flaga", "flagb"}, e.Peers)
+	}
+
+	r = cli.Parser().PrefixMatch(true).ParseArgs("cli", "o", "x")
+	if a.Error(r.Error) {
+		a.IsType(&AmbiguousCmdError{}, r.Error)
+		a.Equal("ambiguous command o, could be objects, output", r.Error.Error())
+		a.Equal([]string{"o", "x"}, r.UnparsedArgs)
+	}
+
+	// disabled by default
+	r = cli.ParseArgs("cli", "do", "--fl=f1")
+	a.True(r.MissingCmd)
+	r = cli.ParseArgs("cli", "down", "--fl=f1")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		a.Equal(VarErrNoDef, r.CmdStack[1].Errs[0].ErrType)
+	}
+}
+
+func TestLocalOptions(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "--force", "down")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.True(r.CmdStack[0].Vars["force"].(bool))
+	}
+
+	r = cli.ParseArgs("cli", "down", "--force")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		a.Equal("force", r.CmdStack[1].Errs[0].Name)
+		a.Equal(VarErrNoDef, r.CmdStack[1].Errs[0].ErrType)
+		a.False(r.CmdStack[0].Vars["force"].(bool))
+	}
+
+	r = cli.Parser().PrefixMatch(true).ParseArgs("cli", "down", "--forc")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		a.Equal(VarErrNoDef, r.CmdStack[1].Errs[0].ErrType)
+	}
+}
+
+func TestCountOptions(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "counts")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal(int64(0), r.CmdStack[1].Vars["verbose"])
+		a.Equal(int64(1), r.CmdStack[1].Vars["level"])
+	}
+
+	r = cli.ParseArgs("cli", "counts", "-vqv", "--verbose", "-l")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal(int64(3), cs.Vars["verbose"])
+		a.True(cs.Vars["quiet"].(bool))
+		a.Equal(int64(2), cs.Vars["level"])
+		a.Equal("3", cs.Opts["verbose"])
+	}
+
+	r = cli.ParseArgs("cli", "counts", "--verbose=5", "-v", "--no-level")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal(int64(6), r.CmdStack[1].Vars["verbose"])
+		a.Equal(int64(0), r.CmdStack[1].Vars["level"])
+	}
+
+	r = cli.ParseArgs("cli", "counts", "-vvv", "--no-verbose", "-v")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal(int64(1), r.CmdStack[1].Vars["verbose"])
+	}
+
+	r = cli.ParseArgs("cli", "counts", "-lll", "--verbose=x")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 2) {
+		a.Equal("level", r.CmdStack[1].Errs[0].Name)
+		a.Equal(VarErrBadVal, r.CmdStack[1].Errs[0].ErrType)
+		a.Equal("verbose", r.CmdStack[1].Errs[1].Name)
+		a.Equal(VarErrBadVal, r.CmdStack[1].Errs[1].ErrType)
+	}
+}
+
+func TestDashValues(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "calc", "-5", "3", "-.5", "--offset", "-20", "--pattern", "--x")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal([]interface{}{float64(-5), float64(3), float64(-0.5)}, cs.Vars["nums"])
+		a.Equal(int64(-20), cs.Vars["offset"])
+		a.Equal("--x", cs.Vars["pattern"])
+	}
+
+	r = cli.ParseArgs("cli", "calc", "-1", "-2", "--offset", "-1")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 1) {
+		cs := r.CmdStack[1]
+		// -1 is a defined short option
+		a.True(cs.Vars["one"].(bool))
+		a.Equal([]interface{}{float64(-2)}, cs.Vars["nums"])
+		a.Equal("offset", cs.Errs[0].Name)
+		a.Equal(VarErrNoVal, cs.Errs[0].ErrType)
+	}
+
+	r = cli.ParseArgs("cli", "calc", "--offset", "-x", "-in")
+	if a.Len(r.CmdStack, 2) && a.Len(r.CmdStack[1].Errs, 4) {
+		a.Equal(VarErrNoVal, r.CmdStack[1].Errs[0].ErrType)
+		for i, name := range []string{"x", "i", "n"} {
+			a.Equal(VarErrNoDef, r.CmdStack[1].Errs[i+1].ErrType)
+			a.Equal(name, r.CmdStack[1].Errs[i+1].Name)
+		}
+	}
+
+	pt := cli.Parser().Complete("cli", "calc", "--pattern", "-")
+	a.Equal(CompleteOptVal, pt.Kind)
+	pt = cli.Parser().Complete("cli", "calc", "--offset", "-")
+	a.Equal(CompleteOptName, pt.Kind)
+}
+
+func TestStopAtArgs(t *testing.T) {
+	a := assert.New(t)
+	r := cli.ParseArgs("cli", "launch", "-e", "a=1", "ls", "-l", "--", "--color", "-e")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.Equal(map[string]interface{}{"a": "1"}, cs.Vars["env"])
+		a.Equal("ls", cs.Vars["program"])
+		a.Equal([]interface{}{"-l", "--", "--color", "-e"}, cs.Vars["args"])
+		a.Equal([]string{"ls", "-l", "--", "--color", "-e"}, cs.Args)
+		a.Equal([]string{"-l", "--", "--color", "-e"}, r.UnparsedArgs)
+	}
+
+	r = cli.ParseArgs("cli", "exec", "-q", "ls", "-q", "a")
+	if a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		cs := r.CmdStack[1]
+		a.True(cs.Vars["quiet"].(bool))
+		a.Equal([]string{"ls", "-q", "a"}, cs.Args)
+		a.Equal([]string{"ls", "-q", "a"}, r.UnparsedArgs)
+	}
+
+	pt := cli.Parser().Complete("cli", "launch", "ls", "-")
+	a.Equal(CompleteArg, pt.Kind)
+}
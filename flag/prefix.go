@@ -0,0 +1,71 @@
+package flag
+
+import (
+	"sort"
+	"strings"
+)
+
+// AmbiguousCmdError is the error when a prefix matches multiple subcommands
+type AmbiguousCmdError struct {
+	Name       string
+	Candidates []string
+}
+
+func (e *AmbiguousCmdError) Error() string {
+	return "ambiguous command " + e.Name + ", could be " + strings.Join(e.Candidates, ", ")
+}
+
+// MatchOptions finds options with a long name or alias starting with prefix,
+// and maps each option to its name if matched, otherwise the first matched alias
+func (cmd *Command) MatchOptions(prefix string) map[*Option]string {
+	found := make(map[*Option]string)
+	for _, opt := range cmd.Options {
+		if name, ok := matchPrefix(prefix, opt.Name, opt.Alias); ok {
+			found[opt] = name
+		}
+	}
+	return found
+}
+
+// MatchCommands finds subcommands with a name or alias starting with prefix,
+// and maps each subcommand to its name if matched, otherwise the first matched alias
+func (cmd *Command) MatchCommands(prefix string) map[*Command]string {
+	found := make(map[*Command]string)
+	for _, sub := range cmd.Commands {
+		if name, ok := matchPrefix(prefix, sub.Name, sub.Alias); ok {
+			found[sub] = name
+		}
+	}
+	return found
+}
+
+func matchPrefix(prefix, name string, alias []string) (string, bool) {
+	if prefix == "" {
+		return "", false
+	}
+	for _, n := range append([]string{name}, alias...) {
+		if len(n) > 1 && strings.HasPrefix(n, prefix) {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// prefixWarnings reports aliases which are prefixes of other names, as the
+// alias always wins and the other name can't be matched by that prefix
+func prefixWarnings(cmdPath string, names map[string]string) []string {
+	var warnings []string
+	for alias, owner := range names {
+		if alias == owner || len(alias) < 2 {
+			continue
+		}
+		for name, other := range names {
+			if other != owner && name != alias && strings.HasPrefix(name, alias) {
+				warnings = append(warnings,
+					cmdPath+": alias "+alias+" of "+owner+" is a prefix of "+name)
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
@@ -0,0 +1,299 @@
+package flag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// varErrTypeNames names the VarError types in records
+var varErrTypeNames = []string{
+	VarErrNoDef:      "no-def",
+	VarErrNoVal:      "no-val",
+	VarErrBadVal:     "bad-val",
+	VarErrExclusive:  "exclusive",
+	VarErrRequires:   "requires",
+	VarErrAtLeastOne: "at-least-one",
+	VarErrAmbiguous:  "ambiguous",
+}
+
+// varErrorRecord is the serialized VarError, the definition is
+// referred by name
+type varErrorRecord struct {
+	Name   string   `json:"name" yaml:"name"`
+	Value  *string  `json:"value,omitempty" yaml:"value,omitempty"`
+	Def    string   `json:"def,omitempty" yaml:"def,omitempty"`
+	Type   string   `json:"type" yaml:"type"`
+	File   string   `json:"file,omitempty" yaml:"file,omitempty"`
+	Line   int      `json:"line,omitempty" yaml:"line,omitempty"`
+	Peers  []string `json:"peers,omitempty" yaml:"peers,omitempty"`
+	Reason string   `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// parsedCmdRecord is the serialized ParsedCmd, the command is referred by name
+type parsedCmdRecord struct {
+	Cmd        string                 `json:"cmd" yaml:"cmd"`
+	Args       []string               `json:"args,omitempty" yaml:"args,omitempty"`
+	ParsedArgC int                    `json:"parsed-argc,omitempty" yaml:"parsed-argc,omitempty"`
+	Vars       map[string]interface{} `json:"vars,omitempty" yaml:"vars,omitempty"`
+	Opts       map[string]string      `json:"opts,omitempty" yaml:"opts,omitempty"`
+	Envs       map[string]string      `json:"envs,omitempty" yaml:"envs,omitempty"`
+	Sources    map[string]string      `json:"sources,omitempty" yaml:"sources,omitempty"`
+	Errs       []*varErrorRecord      `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// parseResultRecord is the serialized ParseResult
+type parseResultRecord struct {
+	Program      string             `json:"program" yaml:"program"`
+	CmdStack     []*parsedCmdRecord `json:"cmd-stack" yaml:"cmd-stack"`
+	UnparsedArgs []string           `json:"unparsed-args,omitempty" yaml:"unparsed-args,omitempty"`
+	MissingCmd   bool               `json:"missing-cmd,omitempty" yaml:"missing-cmd,omitempty"`
+	ExpectCmd    bool               `json:"expect-cmd,omitempty" yaml:"expect-cmd,omitempty"`
+	Error        string             `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func (e *VarError) record() *varErrorRecord {
+	rec := &varErrorRecord{
+		Name:   e.Name,
+		Value:  e.Value,
+		Type:   fmt.Sprintf("%d", e.ErrType),
+		File:   e.File,
+		Line:   e.Line,
+		Peers:  e.Peers,
+		Reason: e.Reason,
+	}
+	if e.ErrType >= 0 && e.ErrType < len(varErrTypeNames) {
+		rec.Type = varErrTypeNames[e.ErrType]
+	}
+	if e.Def != nil {
+		rec.Def = e.Def.Name
+	}
+	return rec
+}
+
+func (pcmd *ParsedCmd) record() *parsedCmdRecord {
+	rec := &parsedCmdRecord{
+		Cmd:        pcmd.Cmd.Name,
+		Args:       pcmd.Args,
+		ParsedArgC: pcmd.ParsedArgC,
+		Vars:       make(map[string]interface{}),
+		Opts:       pcmd.Opts,
+		Envs:       pcmd.Envs,
+		Sources:    pcmd.Sources,
+	}
+	for name, val := range pcmd.Vars {
+		rec.Vars[name] = recordVal(val)
+	}
+	for _, e := range pcmd.Errs {
+		rec.Errs = append(rec.Errs, e.record())
+	}
+	return rec
+}
+
+func (r *ParseResult) record() *parseResultRecord {
+	rec := &parseResultRecord{
+		Program:      r.Program,
+		UnparsedArgs: r.UnparsedArgs,
+		MissingCmd:   r.MissingCmd,
+		ExpectCmd:    r.ExpectCmd,
+	}
+	for _, pcmd := range r.CmdStack {
+		rec.CmdStack = append(rec.CmdStack, pcmd.record())
+	}
+	if r.Error != nil {
+		rec.Error = r.Error.Error()
+	}
+	return rec
+}
+
+// recordVal converts the values of built-in types to strings
+// which can be parsed back
+func recordVal(val interface{}) interface{} {
+	if val == nil {
+		return nil
+	}
+	switch v := val.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = recordVal(item)
+		}
+		return list
+	case map[string]interface{}:
+		dict := make(map[string]interface{})
+		for k, item := range v {
+			dict[k] = recordVal(item)
+		}
+		return dict
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		if rv := reflect.ValueOf(val); (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Slice) && rv.IsNil() {
+			return nil
+		}
+		return v.String()
+	}
+	return val
+}
+
+// MarshalJSON implements json.Marshaler
+func (e *VarError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.record())
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (e *VarError) MarshalYAML() (interface{}, error) {
+	return e.record(), nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (pcmd *ParsedCmd) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pcmd.record())
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (pcmd *ParsedCmd) MarshalYAML() (interface{}, error) {
+	return pcmd.record(), nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (r *ParseResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.record())
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (r *ParseResult) MarshalYAML() (interface{}, error) {
+	return r.record(), nil
+}
+
+// LoadParseResult rebuilds a ParseResult from JSON or YAML marshalled from
+// a ParseResult, the commands and options are resolved in the definition.
+// The extensions used by CliDef are hooked up, so the result can be
+// executed by Exec.
+func (d *CliDef) LoadParseResult(data []byte) (*ParseResult, error) {
+	rec := &parseResultRecord{}
+	if err := yaml.Unmarshal(data, rec); err != nil {
+		return nil, err
+	}
+	p := d.Parser()
+	r := &p.result
+	r.Program = rec.Program
+	r.UnparsedArgs = rec.UnparsedArgs
+	r.MissingCmd = rec.MissingCmd
+	r.ExpectCmd = rec.ExpectCmd
+	if rec.Error != "" {
+		r.Error = errors.New(rec.Error)
+	}
+	var parent *Command
+	for _, cmdRec := range rec.CmdStack {
+		cmd := d.Cli
+		if parent != nil {
+			cmd = parent.FindCommand(cmdRec.Cmd)
+		}
+		if cmd == nil || cmd.Name != cmdRec.Cmd {
+			return nil, errors.New(errMsgRecordNoCmd + cmdRec.Cmd)
+		}
+		pcmd, err := cmdRec.load(cmd, r.CmdStack)
+		if err != nil {
+			return nil, err
+		}
+		r.CmdStack = append(r.CmdStack, pcmd)
+		parent = cmd
+	}
+	return r, nil
+}
+
+func (rec *parsedCmdRecord) load(cmd *Command, parents []*ParsedCmd) (*ParsedCmd, error) {
+	pcmd := &ParsedCmd{
+		Cmd:        cmd,
+		Args:       rec.Args,
+		ParsedArgC: rec.ParsedArgC,
+		Vars:       make(map[string]interface{}),
+		Opts:       rec.Opts,
+		Envs:       rec.Envs,
+		Sources:    rec.Sources,
+	}
+	if pcmd.Opts == nil {
+		pcmd.Opts = make(map[string]string)
+	}
+	if pcmd.Envs == nil {
+		pcmd.Envs = make(map[string]string)
+	}
+	if pcmd.Sources == nil {
+		pcmd.Sources = make(map[string]string)
+	}
+	for name, raw := range rec.Vars {
+		val := plainVal(raw)
+		if opt := cmd.FindOptArg(name); opt != nil && opt.Name == name {
+			var err error
+			if val, err = opt.loadVal(val); err != nil {
+				return nil, fmt.Errorf("%s: %s: %v", cmd.Name, name, err)
+			}
+		}
+		pcmd.Vars[name] = val
+	}
+	for _, errRec := range rec.Errs {
+		varErr := &VarError{
+			Name:    errRec.Name,
+			Value:   errRec.Value,
+			ErrType: -1,
+			File:    errRec.File,
+			Line:    errRec.Line,
+			Peers:   errRec.Peers,
+			Reason:  errRec.Reason,
+		}
+		for t, name := range varErrTypeNames {
+			if name == errRec.Type {
+				varErr.ErrType = t
+			}
+		}
+		if varErr.ErrType < 0 {
+			return nil, errors.New(errMsgRecordErrType + errRec.Type)
+		}
+		if errRec.Def != "" {
+			varErr.Def = cmd.FindOptArg(errRec.Def)
+			for i := len(parents) - 1; i >= 0 && varErr.Def == nil; i-- {
+				varErr.Def = parents[i].Cmd.FindOption(errRec.Def)
+			}
+			if varErr.Def == nil {
+				return nil, errors.New(errMsgRecordNoDef + errRec.Def)
+			}
+		}
+		pcmd.Errs = append(pcmd.Errs, varErr)
+	}
+	return pcmd, nil
+}
+
+// loadVal converts a loaded value to the type of option
+func (opt *Option) loadVal(val interface{}) (interface{}, error) {
+	if val == nil {
+		if opt.vtype != nil && !opt.List {
+			return opt.vtype.zero, nil
+		}
+		return nil, nil
+	}
+	return opt.ParseVal(val)
+}
+
+// plainVal converts the maps decoded from YAML to map[string]interface{}
+func plainVal(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		dict := make(map[string]interface{})
+		for k, item := range v {
+			dict[fmt.Sprintf("%v", k)] = plainVal(item)
+		}
+		return dict
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = plainVal(item)
+		}
+		return list
+	}
+	return val
+}
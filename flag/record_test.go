@@ -0,0 +1,131 @@
+package flag
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+type testReplayExt struct {
+	vars []map[string]interface{}
+}
+
+func (x *testReplayExt) ExecuteCmd(ctx *ExecContext) {
+	x.vars = append(x.vars, ctx.Cmd().Vars)
+}
+
+func (x *testReplayExt) RegisterExt(parser *Parser) {
+	parser.AddExecExt(x)
+}
+
+func TestMarshalParseResult(t *testing.T) {
+	a := assert.New(t)
+	def, err := DecodeCliDefFile("test.yml")
+	if !a.NoError(err) {
+		return
+	}
+	r := def.ParseArgs("cli", "-s", "s1", "types", "--timeout=1m30s", "--since=2020-01-02",
+		"--limit=1.5G", "--addr=10.0.0.1", "--addr=::1", "--net=10.1.0.0/16",
+		"--endpoint=https://example.com/api", "--unknown")
+	if !a.Len(r.CmdStack, 2) {
+		return
+	}
+	data, err := json.Marshal(r)
+	if !a.NoError(err) {
+		return
+	}
+	var raw map[string]interface{}
+	if a.NoError(json.Unmarshal(data, &raw)) {
+		a.Equal("cli", raw["program"])
+		stack := raw["cmd-stack"].([]interface{})
+		if a.Len(stack, 2) {
+			cmd := stack[1].(map[string]interface{})
+			a.Equal("types", cmd["cmd"])
+			a.Equal("1m30s", cmd["vars"].(map[string]interface{})["timeout"])
+			errs := cmd["errors"].([]interface{})
+			if a.Len(errs, 1) {
+				a.Equal(map[string]interface{}{"name": "unknown", "type": "no-def"}, errs[0])
+			}
+		}
+	}
+
+	for _, marshal := range []func(interface{}) ([]byte, error){json.Marshal, yaml.Marshal} {
+		data, err = marshal(r)
+		if !a.NoError(err) {
+			continue
+		}
+		loaded, err := def.LoadParseResult(data)
+		if !a.NoError(err) || !a.Len(loaded.CmdStack, 2) {
+			continue
+		}
+		a.Equal("cli", loaded.Program)
+		for i, pcmd := range loaded.CmdStack {
+			a.Equal(r.CmdStack[i].Cmd, pcmd.Cmd)
+			a.Equal(r.CmdStack[i].Vars, pcmd.Vars)
+			a.Equal(r.CmdStack[i].Opts, pcmd.Opts)
+		}
+		if a.Len(loaded.CmdStack[1].Errs, 1) {
+			a.Equal(*r.CmdStack[1].Errs[0], *loaded.CmdStack[1].Errs[0])
+		}
+	}
+}
+
+func TestReplayParseResult(t *testing.T) {
+	a := assert.New(t)
+	def, err := DecodeCliDefFile("test.yml")
+	if !a.NoError(err) {
+		return
+	}
+	x := &testReplayExt{}
+	def.Use(x)
+	r := def.ParseArgs("cli", "map", "--kv=b=b1", "--no-defs", "x", "--", "y")
+	a.NoError(r.Exec())
+	data, err := json.Marshal(r)
+	if !a.NoError(err) {
+		return
+	}
+	loaded, err := def.LoadParseResult(data)
+	if a.NoError(err) {
+		a.NoError(loaded.Exec())
+		a.Equal([]string{"y"}, loaded.UnparsedArgs)
+		a.Equal([]string{"y"}, loaded.CmdStack[1].Args)
+		if a.Len(x.vars, 2) {
+			a.Equal(x.vars[0], x.vars[1])
+			a.Equal(map[string]interface{}{"a": "a1", "b": "b1"}, x.vars[1]["kv"])
+		}
+	}
+
+	bad := []string{
+		`{"program": "cli", "cmd-stack": [{"cmd": "cli"}, {"cmd": "nothing"}]}`,
+		`{"program": "cli", "cmd-stack": [{"cmd": "cli", "vars": {"server": [1]}}]}`,
+		`{"program": "cli", "cmd-stack": [{"cmd": "cli", "errors": [{"name": "x", "type": "bad"}]}]}`,
+		`not json`,
+	}
+	for _, data := range bad {
+		_, err = def.LoadParseResult([]byte(data))
+		a.Error(err, data)
+	}
+}
+
+func TestLoadParseResultUnknownDef(t *testing.T) {
+	a := assert.New(t)
+	def, err := DecodeCliDefString(`---
+cli:
+    name: t
+    options:
+        - name: new
+`)
+	if !a.NoError(err) {
+		return
+	}
+	_, err = def.LoadParseResult([]byte(`{"cmd-stack":[{"cmd":"t","errors":[{"name":"old","def":"old","type":"no-val"}]}]}`))
+	if a.Error(err) {
+		a.Contains(err.Error(), "old")
+	}
+	loaded, err := def.LoadParseResult([]byte(`{"cmd-stack":[{"cmd":"t","errors":[{"name":"new","def":"new","type":"no-val"}]}]}`))
+	if a.NoError(err) && a.Len(loaded.CmdStack[0].Errs, 1) {
+		a.Equal("new", loaded.CmdStack[0].Errs[0].Def.Name)
+	}
+}
@@ -0,0 +1,204 @@
+package flag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// MaxResponseFileDepth limits the nesting of response files
+var MaxResponseFileDepth = 16
+
+// ArgToken is an argument split from text
+type ArgToken struct {
+	Arg string
+	// Line is the line number (starting from 1) where the argument starts
+	Line int
+	// Quoted indicates any part of the argument is quoted or escaped
+	Quoted bool
+}
+
+// SplitError is the error when text can't be split into arguments
+type SplitError struct {
+	Line   int
+	Reason string
+}
+
+func (e *SplitError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// ResponseFileError is the error when expanding a response file
+type ResponseFileError struct {
+	File string
+	// Line is the line in File, 0 if the error is not about the content
+	Line   int
+	Reason string
+}
+
+func (e *ResponseFileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+	}
+	return e.File + ": " + e.Reason
+}
+
+// SplitArgTokens splits text into arguments like a POSIX shell:
+// arguments are separated by whitespaces including newlines,
+// single quotes preserve everything literally, double quotes allow
+// backslash to escape '"', '\\', '$' and newline, backslash outside
+// quotes escapes any char, and '#' at the beginning of an argument
+// starts a comment till the end of line.
+func SplitArgTokens(text string) ([]ArgToken, error) {
+	var tokens []ArgToken
+	var word strings.Builder
+	var token *ArgToken
+	line := 1
+	chars := []rune(text)
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		switch {
+		case c == '\n' || c == ' ' || c == '\t' || c == '\r':
+			if token != nil {
+				token.Arg = word.String()
+				tokens = append(tokens, *token)
+				token = nil
+				word.Reset()
+			}
+			if c == '\n' {
+				line++
+			}
+			continue
+		case c == '#' && token == nil:
+			for i+1 < len(chars) && chars[i+1] != '\n' {
+				i++
+			}
+			continue
+		}
+		if token == nil {
+			token = &ArgToken{Line: line}
+		}
+		switch c {
+		case '\\':
+			if i+1 >= len(chars) {
+				return nil, &SplitError{line, "backslash at end of text"}
+			}
+			i++
+			if chars[i] == '\n' {
+				// line continuation
+				line++
+			} else {
+				word.WriteRune(chars[i])
+			}
+			token.Quoted = true
+		case '\'':
+			start := line
+			for i++; i < len(chars) && chars[i] != '\''; i++ {
+				if chars[i] == '\n' {
+					line++
+				}
+				word.WriteRune(chars[i])
+			}
+			if i >= len(chars) {
+				return nil, &SplitError{start, "unterminated single quote"}
+			}
+			token.Quoted = true
+		case '"':
+			start := line
+			for i++; i < len(chars) && chars[i] != '"'; i++ {
+				if chars[i] == '\\' && i+1 < len(chars) &&
+					strings.ContainsRune("\"\\$\n", chars[i+1]) {
+					i++
+					if chars[i] == '\n' {
+						line++
+						continue
+					}
+				} else if chars[i] == '\n' {
+					line++
+				}
+				word.WriteRune(chars[i])
+			}
+			if i >= len(chars) {
+				return nil, &SplitError{start, "unterminated double quote"}
+			}
+			token.Quoted = true
+		default:
+			word.WriteRune(c)
+		}
+	}
+	if token != nil {
+		token.Arg = word.String()
+		tokens = append(tokens, *token)
+	}
+	return tokens, nil
+}
+
+// SplitArgs splits text into arguments, see SplitArgTokens
+func SplitArgs(text string) ([]string, error) {
+	tokens, err := SplitArgTokens(text)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(tokens))
+	for i, t := range tokens {
+		args[i] = t.Arg
+	}
+	return args, nil
+}
+
+// ExpandResponseFile reads arguments from a response file, and unquoted
+// arguments like @path are expanded recursively. A relative path in
+// a response file is relative to the directory of that file.
+func ExpandResponseFile(fn string) ([]string, error) {
+	return expandResponseFile(fn, nil)
+}
+
+func expandResponseFile(fn string, including []string) ([]string, error) {
+	absFn, err := filepath.Abs(fn)
+	if err != nil {
+		return nil, &ResponseFileError{File: fn, Reason: err.Error()}
+	}
+	for i, f := range including {
+		if f == absFn {
+			chain := append(append([]string{}, including[i:]...), absFn)
+			return nil, &ResponseFileError{File: fn, Reason: "include cycle: " + strings.Join(chain, " -> ")}
+		}
+	}
+	if len(including) >= MaxResponseFileDepth {
+		return nil, &ResponseFileError{File: fn,
+			Reason: fmt.Sprintf("nested too deep (more than %d levels)", MaxResponseFileDepth)}
+	}
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, &ResponseFileError{File: fn, Reason: err.Error()}
+	}
+	tokens, err := SplitArgTokens(string(data))
+	if err != nil {
+		splitErr := err.(*SplitError)
+		return nil, &ResponseFileError{File: fn, Line: splitErr.Line, Reason: splitErr.Reason}
+	}
+	including = append(including, absFn)
+	var args []string
+	for _, t := range tokens {
+		if t.Quoted || len(t.Arg) < 2 || t.Arg[0] != '@' {
+			args = append(args, t.Arg)
+			continue
+		}
+		nested := t.Arg[1:]
+		if !filepath.IsAbs(nested) {
+			nested = filepath.Join(filepath.Dir(fn), nested)
+		}
+		nestedArgs, err := expandResponseFile(nested, including)
+		if err != nil {
+			if fileErr, ok := err.(*ResponseFileError); ok && fileErr.Line == 0 {
+				// locate the include in current file
+				fileErr.Reason = fileErr.File + ": " + fileErr.Reason
+				fileErr.File, fileErr.Line = fn, t.Line
+			}
+			return nil, err
+		}
+		args = append(args, nestedArgs...)
+	}
+	return args, nil
+}
@@ -0,0 +1,112 @@
+package flag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitArgs(t *testing.T) {
+	a := assert.New(t)
+	args, err := SplitArgs(`a 'b c' "d \"e\" \$f" g\ h # comment
+	i#j "k
+l" \
+m`)
+	if a.NoError(err) {
+		a.Equal([]string{"a", "b c", `d "e" $f`, "g h", "i#j", "k\nl", "m"}, args)
+	}
+
+	tokens, err := SplitArgTokens("a\n'@b'\n\n@c")
+	if a.NoError(err) && a.Len(tokens, 3) {
+		a.Equal(ArgToken{Arg: "a", Line: 1}, tokens[0])
+		a.Equal(ArgToken{Arg: "@b", Line: 2, Quoted: true}, tokens[1])
+		a.Equal(ArgToken{Arg: "@c", Line: 4}, tokens[2])
+	}
+
+	_, err = SplitArgs("a\n'b\nc")
+	if a.Error(err) {
+		a.Equal("line 2: unterminated single quote", err.Error())
+	}
+	_, err = SplitArgs(`a "b`)
+	a.Error(err)
+	_, err = SplitArgs(`a \`)
+	a.Error(err)
+}
+
+func writeRespFile(t *testing.T, dir, name, content string) string {
+	fn := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestResponseFiles(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "clix-respfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeRespFile(t, dir, "opts", "# options\n--server=s1\ndown @flags '@lit'\n")
+	writeRespFile(t, dir, "flags", "-w --flag 'a b'\n")
+	fn := filepath.Join(dir, "opts")
+
+	r := cli.Parser().ResponseFiles(true).ParseArgs("cli", "@"+fn, "c")
+	if a.NoError(r.Error) && a.False(r.HasErrors()) && a.Len(r.CmdStack, 2) {
+		a.Equal("s1", r.CmdStack[0].Vars["server"])
+		cs := r.CmdStack[1]
+		a.Equal("down", cs.Cmd.Name)
+		a.True(cs.Vars["wait"].(bool))
+		a.Equal("a b", cs.Vars["flag"])
+		a.Equal([]string{"@lit", "c"}, cs.Args)
+	}
+
+	r = cli.ParseArgs("cli", "down", "@"+fn)
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.Equal([]string{"@" + fn}, r.CmdStack[1].Args)
+	}
+
+	r = cli.Parser().ResponseFiles(true).ParseArgs("cli", "down", "--", "@"+fn)
+	if a.NoError(r.Error) && a.Len(r.CmdStack, 2) {
+		a.Equal([]string{"@" + fn}, r.CmdStack[1].Args)
+	}
+
+	writeRespFile(t, dir, "bad", "down\n--flag 'x\n")
+	r = cli.Parser().ResponseFiles(true).ParseArgs("cli", "@"+filepath.Join(dir, "bad"), "x")
+	if a.Error(r.Error) {
+		a.Equal(filepath.Join(dir, "bad")+":2: unterminated single quote", r.Error.Error())
+		a.Equal([]string{"x"}, r.UnparsedArgs)
+	}
+
+	writeRespFile(t, dir, "missing", "down\n@not-exist\n")
+	r = cli.Parser().ResponseFiles(true).ParseArgs("cli", "@"+filepath.Join(dir, "missing"))
+	if a.Error(r.Error) {
+		fileErr, ok := r.Error.(*ResponseFileError)
+		if a.True(ok) {
+			a.Equal(filepath.Join(dir, "missing"), fileErr.File)
+			a.Equal(2, fileErr.Line)
+		}
+	}
+
+	writeRespFile(t, dir, "cycle1", "@cycle2")
+	writeRespFile(t, dir, "cycle2", "\n@cycle1")
+	r = cli.Parser().ResponseFiles(true).ParseArgs("cli", "@"+filepath.Join(dir, "cycle1"))
+	if a.Error(r.Error) {
+		a.Contains(r.Error.Error(), "include cycle")
+		a.Contains(r.Error.Error(), filepath.Join(dir, "cycle2")+":2:")
+	}
+
+	writeRespFile(t, dir, "self", "@self")
+	depth := MaxResponseFileDepth
+	MaxResponseFileDepth = 0
+	defer func() { MaxResponseFileDepth = depth }()
+	r = cli.Parser().ResponseFiles(true).ParseArgs("cli", "@"+filepath.Join(dir, "self"))
+	if a.Error(r.Error) {
+		a.Contains(r.Error.Error(), "nested too deep")
+	}
+}
@@ -0,0 +1,197 @@
+package flag
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	merr "github.com/easeway/langx.go/errors"
+)
+
+// SchemaID is the identifier of the JSON Schema of CLI definitions
+const SchemaID = "https://github.com/codingbrain/clix.go/schema/clidef.schema.json"
+
+// yamlField is a field of a definition struct decoded from YAML
+type yamlField struct {
+	name      string
+	omitEmpty bool
+	typ       reflect.Type
+	index     int
+}
+
+// yamlFields lists the decoded fields of a struct type in order
+func yamlFields(t reflect.Type) []yamlField {
+	var fields []yamlField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		field := yamlField{name: parts[0], typ: f.Type, index: i}
+		if field.name == "" {
+			field.name = strings.ToLower(f.Name)
+		}
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				field.omitEmpty = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func findYamlField(t reflect.Type, name string) *yamlField {
+	for _, f := range yamlFields(t) {
+		if f.name == name {
+			return &f
+		}
+	}
+	return nil
+}
+
+// JSONSchema builds the JSON Schema (draft-07) of CLI definitions
+// from the YAML tags of CliDef, Command and Option
+func JSONSchema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	schema := schemaOfStruct(reflect.TypeOf(CliDef{}), defs)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = SchemaID
+	schema["title"] = "CLI definition"
+	schema["definitions"] = defs
+	return schema
+}
+
+// JSONSchemaBytes encodes the JSON Schema of CLI definitions,
+// the same as the content of schema/clidef.schema.json
+func JSONSchemaBytes() []byte {
+	encoded, err := json.MarshalIndent(JSONSchema(), "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append(encoded, '\n')
+}
+
+func schemaOfStruct(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	for _, f := range yamlFields(t) {
+		props[f.name] = schemaOf(f.typ, defs)
+		if !f.omitEmpty {
+			required = append(required, f.name)
+		}
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func schemaOf(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), defs)
+	case reflect.Struct:
+		if _, exists := defs[t.Name()]; !exists {
+			// reserve the name first for recursive definitions
+			defs[t.Name()] = nil
+			defs[t.Name()] = schemaOfStruct(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), defs)}
+	}
+	// any value
+	return map[string]interface{}{}
+}
+
+// checkKeys reports the keys in decoded YAML which are not defined
+// by the struct type t, path is the key path of val
+func checkKeys(val interface{}, t reflect.Type, path string, loc *defLocator, errs *merr.AggregatedError) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	m, ok := val.(map[interface{}]interface{})
+	if !ok || t.Kind() != reflect.Struct {
+		return
+	}
+	vals := make(map[string]interface{})
+	keys := make([]string, 0, len(m))
+	for k, v := range m {
+		key := fmt.Sprint(k)
+		vals[key] = v
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		f := findYamlField(t, key)
+		if f == nil {
+			err := &CmdDefError{Command: keyPath, Message: errMsgUnknownKey}
+			if pos, ok := loc.keys[keyPath]; ok {
+				err.Line, err.Column = pos.line, pos.col
+			}
+			errs.Add(err)
+			continue
+		}
+		checkValue(vals[key], f.typ, keyPath, loc, errs)
+	}
+}
+
+// checkValue checks the keys in the structs of value in decoded YAML
+func checkValue(val interface{}, t reflect.Type, path string, loc *defLocator, errs *merr.AggregatedError) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		checkKeys(val, t, path, loc, errs)
+	case reflect.Slice:
+		if items, ok := val.([]interface{}); ok {
+			for i, item := range items {
+				checkValue(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), loc, errs)
+			}
+		}
+	case reflect.Map:
+		if m, ok := val.(map[interface{}]interface{}); ok {
+			keys := make([]string, 0, len(m))
+			vals := make(map[string]interface{})
+			for k, v := range m {
+				key := fmt.Sprint(k)
+				keys = append(keys, key)
+				vals[key] = v
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				checkValue(vals[key], t.Elem(), path+"."+key, loc, errs)
+			}
+		}
+	}
+}
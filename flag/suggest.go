@@ -0,0 +1,100 @@
+package flag
+
+import "sort"
+
+// EditDistance calculates the number of edits (insertion, deletion,
+// substitution and transposition of adjacent chars) between two strings
+func EditDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] is the distance between s[:i] and t[:j]
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+cost)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func minInt(n int, rest ...int) int {
+	for _, v := range rest {
+		if v < n {
+			n = v
+		}
+	}
+	return n
+}
+
+// Suggest returns the candidates which are within maxDist edits from name,
+// the closest ones come first. A candidate is never suggested if all chars
+// of name have to be changed.
+func Suggest(name string, candidates []string, maxDist int) []string {
+	type suggestion struct {
+		name string
+		dist int
+	}
+	var found []suggestion
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if seen[c] || c == name {
+			continue
+		}
+		seen[c] = true
+		if dist := EditDistance(name, c); dist <= maxDist && dist < len(name) {
+			found = append(found, suggestion{name: c, dist: dist})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		return found[i].name < found[j].name
+	})
+	names := make([]string, len(found))
+	for i, s := range found {
+		names[i] = s.name
+	}
+	return names
+}
+
+// SuggestCommands suggests names of subcommands (including aliases)
+// similar to the specified one, hidden commands are excluded
+func (cmd *Command) SuggestCommands(name string, maxDist int) []string {
+	names := make([]string, 0, len(cmd.CmdMap))
+	for n, sub := range cmd.CmdMap {
+		if !sub.Hidden {
+			names = append(names, n)
+		}
+	}
+	return Suggest(name, names, maxDist)
+}
+
+// SuggestOptions suggests long names of options (including aliases and
+// "no-" forms of bool options) similar to the specified one, hidden
+// options are excluded
+func (cmd *Command) SuggestOptions(name string, maxDist int) []string {
+	names := make([]string, 0, len(cmd.OptMap))
+	for n, opt := range cmd.OptMap {
+		if len(n) > 1 && !opt.Hidden {
+			names = append(names, n)
+			if !opt.ExpectValue() {
+				names = append(names, "no-"+n)
+			}
+		}
+	}
+	return Suggest(name, names, maxDist)
+}
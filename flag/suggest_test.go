@@ -0,0 +1,49 @@
+package flag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(0, EditDistance("", ""))
+	a.Equal(3, EditDistance("abc", ""))
+	a.Equal(1, EditDistance("sever", "server"))
+	a.Equal(1, EditDistance("sevrer", "server"))
+	a.Equal(1, EditDistance("donw", "down"))
+	a.Equal(3, EditDistance("kitten", "sitting"))
+}
+
+func TestSuggest(t *testing.T) {
+	a := assert.New(t)
+	a.Equal([]string{"down"}, cli.Cli.SuggestCommands("donw", 2))
+	a.Equal([]string{"map", "up"}, cli.Cli.SuggestCommands("mup", 2))
+	a.Empty(cli.Cli.SuggestCommands("x", 2))
+	a.Equal([]string{"server"}, cli.Cli.SuggestOptions("sevrer", 2))
+	down := cli.Cli.FindCommand("down")
+	a.Equal([]string{"no-wait", "wait"}, down.SuggestOptions("nowait", 2))
+	a.Empty(down.SuggestOptions("f", 2))
+}
+
+func TestSuggestHidden(t *testing.T) {
+	a := assert.New(t)
+	cmd, err := DecodeCmdsString(`---
+        name: cmd
+        options:
+            - name: debug
+              type: bool
+              hidden: true
+            - name: debugger
+        commands:
+            - name: status
+            - name: stats
+              hidden: true
+    `)
+	if a.NoError(err) {
+		a.Equal([]string{"status"}, cmd.SuggestCommands("stat", 2))
+		a.Equal([]string{"debugger"}, cmd.SuggestOptions("debugg", 2))
+		a.Empty(cmd.SuggestOptions("no-debu", 2))
+	}
+}
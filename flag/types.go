@@ -0,0 +1,168 @@
+package flag
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueType is a built-in type beyond the basic kinds, the values are
+// parsed from strings into specific Go types:
+//
+//	duration  time.Duration, e.g. 1h30m
+//	time      time.Time, RFC3339 or date only
+//	size      int64 in bytes, e.g. 512, 10MiB, 1.5G
+//	ip        net.IP
+//	cidr      *net.IPNet
+//	url       *url.URL, must be absolute
+//
+// Options of these types have ValueKind reflect.Interface.
+type valueType struct {
+	// format describes the expected format in error messages
+	format string
+	parse  func(string) (interface{}, error)
+	// zero is the value when neither specified nor defaulted
+	zero interface{}
+	// numeric accepts numbers as int64 values
+	numeric bool
+}
+
+var valueTypes = map[string]*valueType{
+	"duration": {
+		format: "duration like 1h30m or 10s",
+		parse:  parseDuration,
+		zero:   time.Duration(0),
+	},
+	"time": {
+		format: "time like 2006-01-02T15:04:05Z or date like 2006-01-02",
+		parse:  parseTime,
+		zero:   time.Time{},
+	},
+	"size": {
+		format:  "size like 512, 10MiB or 1.5G",
+		parse:   parseSize,
+		zero:    int64(0),
+		numeric: true,
+	},
+	"ip": {
+		format: "IP address like 10.0.0.1 or ::1",
+		parse:  parseIP,
+		zero:   net.IP(nil),
+	},
+	"cidr": {
+		format: "CIDR like 10.0.0.0/8",
+		parse:  parseCIDR,
+		zero:   (*net.IPNet)(nil),
+	},
+	"url": {
+		format: "absolute URL like https://host/path",
+		parse:  parseURL,
+		zero:   (*url.URL)(nil),
+	},
+}
+
+func (t *valueType) parseStr(str string) (interface{}, error) {
+	val, err := t.parse(str)
+	if err != nil {
+		return nil, &ValueError{"expect " + t.format}
+	}
+	return val, nil
+}
+
+func (t *valueType) convert(val interface{}) (interface{}, error) {
+	if str, ok := val.(string); ok {
+		return t.parseStr(str)
+	}
+	if reflect.TypeOf(val) == reflect.TypeOf(t.zero) {
+		return val, nil
+	}
+	if t.numeric {
+		if num, ok := numVal(val); ok {
+			return int64(num), nil
+		}
+	}
+	return nil, &ValueError{"expect " + t.format}
+}
+
+func parseDuration(str string) (interface{}, error) {
+	return time.ParseDuration(str)
+}
+
+func parseTime(str string) (interface{}, error) {
+	if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", str)
+}
+
+// sizeUnits are multipliers of size suffixes, K, M, G, T, P are decimal,
+// and Ki, Mi, Gi, Ti, Pi are binary, optionally followed by B
+var sizeUnits = map[string]float64{
+	"":   1,
+	"k":  1e3,
+	"m":  1e6,
+	"g":  1e9,
+	"t":  1e12,
+	"p":  1e15,
+	"ki": 1 << 10,
+	"mi": 1 << 20,
+	"gi": 1 << 30,
+	"ti": 1 << 40,
+	"pi": 1 << 50,
+}
+
+func parseSize(str string) (interface{}, error) {
+	str = strings.TrimSpace(str)
+	pos := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if pos < 0 {
+		pos = len(str)
+	}
+	num, err := strconv.ParseFloat(str[:pos], 64)
+	if err != nil {
+		return nil, err
+	}
+	unit := strings.ToLower(strings.TrimSpace(str[pos:]))
+	unit = strings.TrimSuffix(unit, "b")
+	mul, ok := sizeUnits[unit]
+	if !ok {
+		return nil, errors.New("unknown unit: " + str[pos:])
+	}
+	size := num * mul
+	if size > math.MaxInt64 {
+		return nil, errors.New("size too large: " + str)
+	}
+	return int64(size + 0.5), nil
+}
+
+func parseIP(str string) (interface{}, error) {
+	if ip := net.ParseIP(str); ip != nil {
+		return ip, nil
+	}
+	return nil, errors.New("invalid IP address: " + str)
+}
+
+func parseCIDR(str string) (interface{}, error) {
+	_, ipNet, err := net.ParseCIDR(str)
+	if err != nil {
+		return nil, err
+	}
+	return ipNet, nil
+}
+
+func parseURL(str string) (interface{}, error) {
+	u, err := url.Parse(str)
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, errors.New("URL is not absolute: " + str)
+	}
+	return u, nil
+}
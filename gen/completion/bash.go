@@ -0,0 +1,85 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/codingbrain/clix.go/gen"
+)
+
+func genBash(w *gen.Writer, program string, nodes []*cmdNode) {
+	fn := "_" + identifier(program) + "_completion"
+	root := nodes[0].id
+	w.Writeln("# bash completion for %s", program)
+	w.Writeln("%s() {", fn)
+	w1 := w.Indent()
+	w1.Writeln(`local cur="${COMP_WORDS[COMP_CWORD]}" cmd=%s i opts="" cmds=""`, root)
+	w1.Writeln("for ((i = 1; i < COMP_CWORD; i++)); do")
+	w2 := w1.Indent()
+	w2.Writeln(`case "$cmd:${COMP_WORDS[i]}" in`)
+	w3 := w2.Indent()
+	for _, n := range nodes {
+		for _, t := range n.transitions() {
+			var patterns []string
+			for _, name := range t.names {
+				patterns = append(patterns, singleQuote(n.id+":"+name))
+			}
+			w3.Writeln("%s) cmd=%s ;;", strings.Join(patterns, "|"), t.id)
+		}
+	}
+	w2.Writeln("esac")
+	w1.Writeln("done")
+	w1.Writeln(`case "$cmd" in`)
+	w2 = w1.Indent()
+	for _, n := range nodes {
+		var opts, cmds []string
+		for _, opt := range n.opts {
+			opts = append(opts, OptionWords(opt)...)
+		}
+		for _, sub := range VisibleCommands(n.cmd) {
+			cmds = append(cmds, cmdNames(sub)...)
+		}
+		w2.Writeln("%s)", n.id)
+		w3 := w2.Indent()
+		if len(opts) > 0 {
+			w3.Writeln("opts=%s", singleQuote(strings.Join(opts, " ")))
+		}
+		if len(cmds) > 0 {
+			w3.Writeln("cmds=%s", singleQuote(strings.Join(cmds, " ")))
+		}
+		w3.Writeln(";;")
+	}
+	w1.Writeln("esac")
+	w1.Writeln(`if [[ "$cur" == -* ]]; then`)
+	w1.Indent().Writeln(`COMPREPLY=($(compgen -W "$opts" -- "$cur"))`)
+	w1.Writeln(`elif [ -n "$cmds" ]; then`)
+	w1.Indent().Writeln(`COMPREPLY=($(compgen -W "$cmds" -- "$cur"))`)
+	w1.Writeln("else")
+	w1.Indent().Writeln(`COMPREPLY=($(compgen -f -- "$cur"))`)
+	w1.Writeln("fi")
+	w.Writeln("}")
+	w.Writeln("complete -F %s %s", fn, program)
+}
+
+// genBashDynamic emits script calling the program for candidates,
+// bash splits "--name=value" into 3 words which is handled by the program
+func genBashDynamic(w *gen.Writer, program string) {
+	fn := "_" + identifier(program) + "_completion"
+	w.Writeln("# bash completion for %s", program)
+	w.Writeln("%s() {", fn)
+	w1 := w.Indent()
+	w1.Writeln(`local cur="${COMP_WORDS[COMP_CWORD]}" line directive=""`)
+	w1.Writeln("COMPREPLY=()")
+	w1.Writeln("while IFS='' read -r line; do")
+	w2 := w1.Indent()
+	w2.Writeln(`case "$line" in`)
+	w3 := w2.Indent()
+	w3.Writeln(`:*) directive="${line#:}" ;;`)
+	w3.Writeln(`*) COMPREPLY+=("${line%%%%$'\t'*}") ;;`)
+	w2.Writeln("esac")
+	w1.Writeln(`done < <(%s %s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)`, program, CompleteCmdName)
+	w1.Writeln(`if [ "$directive" = %s ]; then`, DirectiveFiles)
+	w1.Indent().Writeln(`COMPREPLY+=($(compgen -f -- "$cur"))`)
+	w1.Writeln("fi")
+	w.Writeln("}")
+	w.Writeln("complete -F %s %s", fn, program)
+}
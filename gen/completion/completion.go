@@ -0,0 +1,239 @@
+package completion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codingbrain/clix.go/flag"
+	"github.com/codingbrain/clix.go/gen"
+)
+
+// Supported shells, also the names of the backends
+const (
+	Bash = "bash"
+	Zsh  = "zsh"
+	Fish = "fish"
+)
+
+// Parameter names
+const (
+	// ParamProgram overrides the program name, default is the root command name
+	ParamProgram = "program"
+	// ParamDynamic generates scripts calling the program for candidates
+	ParamDynamic = "dynamic"
+)
+
+// CompleteCmdName is the hidden command called by dynamic scripts, the
+// program is invoked as
+//
+//	PROGRAM __complete WORDS...
+//
+// where the last word is the one being completed (can be empty).
+// It prints one candidate per line, optionally followed by a TAB and
+// description, and a final line of ":DIRECTIVE", see Directive constants
+const CompleteCmdName = "__complete"
+
+// Directives in the last line of dynamic completion output
+const (
+	// DirectiveNone means only the candidates printed are used
+	DirectiveNone = "none"
+	// DirectiveFiles means the shell should also complete file names
+	DirectiveFiles = "files"
+)
+
+// Shells lists all supported shells
+var Shells = []string{Bash, Zsh, Fish}
+
+// ScriptBackend generates shell completion scripts
+type ScriptBackend struct {
+	// Shell is one of Shells
+	Shell string
+	// Program is the name of the program to be completed
+	Program string
+	// Dynamic generates scripts which call CompleteCmdName of the program
+	Dynamic bool
+}
+
+// cmdNode is a command with the options to complete, see VisibleOption
+type cmdNode struct {
+	id   string
+	cmd  *flag.Command
+	opts []*flag.Option
+}
+
+func newFactory(shell string) gen.BackendFactory {
+	return func(params gen.BackendParams) (gen.Backend, error) {
+		b := &ScriptBackend{Shell: shell}
+		b.Program, _ = params[ParamProgram].(string)
+		switch v := params[ParamDynamic].(type) {
+		case bool:
+			b.Dynamic = v
+		case string:
+			dynamic, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %s", ParamDynamic, v)
+			}
+			b.Dynamic = dynamic
+		}
+		return b, nil
+	}
+}
+
+// GenerateCode implements Backend
+func (b *ScriptBackend) GenerateCode(def *flag.CliDef, w *gen.Writer) error {
+	return b.Generate(w, def.Cli)
+}
+
+// Generate emits completion script for the command tree
+func (b *ScriptBackend) Generate(w *gen.Writer, cmd *flag.Command) error {
+	program := b.Program
+	if program == "" {
+		program = cmd.Name
+	}
+	if b.Dynamic {
+		switch b.Shell {
+		case Bash:
+			genBashDynamic(w, program)
+		case Zsh:
+			genZshDynamic(w, program)
+		case Fish:
+			genFishDynamic(w, program)
+		default:
+			return fmt.Errorf("unsupported shell: %s", b.Shell)
+		}
+		return nil
+	}
+	nodes := walkCommands(identifier(program), cmd, nil)
+	switch b.Shell {
+	case Bash:
+		genBash(w, program, nodes)
+	case Zsh:
+		genZsh(w, program, nodes)
+	case Fish:
+		genFish(w, program, nodes)
+	default:
+		return fmt.Errorf("unsupported shell: %s", b.Shell)
+	}
+	return nil
+}
+
+// walkCommands flattens the command tree in depth-first order, persistent
+// options of parent commands are visible to subcommands
+func walkCommands(id string, cmd *flag.Command, inherited []*flag.Option) []*cmdNode {
+	opts := append([]*flag.Option{}, inherited...)
+	persistent := append([]*flag.Option{}, inherited...)
+	for _, opt := range cmd.Options {
+		if !VisibleOption(opt) {
+			continue
+		}
+		opts = append(opts, opt)
+		if !opt.Local {
+			persistent = append(persistent, opt)
+		}
+	}
+	nodes := []*cmdNode{&cmdNode{id: id, cmd: cmd, opts: opts}}
+	for _, sub := range cmd.Commands {
+		nodes = append(nodes, walkCommands(id+"_"+identifier(sub.Name), sub, persistent)...)
+	}
+	return nodes
+}
+
+// transition switches to the subcommand node by any of the names
+type transition struct {
+	id    string
+	names []string
+}
+
+func (n *cmdNode) transitions() []*transition {
+	var trans []*transition
+	for _, sub := range n.cmd.Commands {
+		trans = append(trans, &transition{
+			id:    n.id + "_" + identifier(sub.Name),
+			names: cmdNames(sub),
+		})
+	}
+	return trans
+}
+
+// VisibleCommands returns the subcommands to complete, hidden and
+// deprecated ones excluded
+func VisibleCommands(cmd *flag.Command) []*flag.Command {
+	var cmds []*flag.Command
+	for _, sub := range cmd.Commands {
+		if !sub.Hidden && !sub.IsDeprecated() {
+			cmds = append(cmds, sub)
+		}
+	}
+	return cmds
+}
+
+// VisibleOption tells whether an option is completed, hidden and
+// deprecated ones are not
+func VisibleOption(opt *flag.Option) bool {
+	return !opt.Hidden && !opt.IsDeprecated()
+}
+
+func cmdNames(cmd *flag.Command) []string {
+	return append([]string{cmd.Name}, cmd.Alias...)
+}
+
+// optNames returns long and short forms of an option,
+// bool options come with "--no-" variants of long names
+func optNames(opt *flag.Option) (long, short []string) {
+	for _, name := range append([]string{opt.Name}, opt.Alias...) {
+		if len(name) == 1 {
+			short = append(short, name)
+		} else {
+			long = append(long, name)
+		}
+	}
+	if !opt.ExpectValue() {
+		for _, name := range long {
+			long = append(long, "no-"+name)
+		}
+	}
+	return
+}
+
+// OptionWords returns the words of an option as typed in command line
+func OptionWords(opt *flag.Option) []string {
+	var words []string
+	long, short := optNames(opt)
+	for _, name := range long {
+		words = append(words, "--"+name)
+	}
+	for _, name := range short {
+		words = append(words, "-"+name)
+	}
+	return words
+}
+
+func identifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Summary returns the first line of description
+func Summary(desc string) string {
+	desc = strings.TrimSpace(desc)
+	if pos := strings.IndexByte(desc, '\n'); pos >= 0 {
+		desc = desc[:pos]
+	}
+	return desc
+}
+
+// singleQuote quotes a string for POSIX shells
+func singleQuote(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
+
+func init() {
+	for _, shell := range Shells {
+		gen.BackendFactories[shell] = newFactory(shell)
+	}
+}
@@ -0,0 +1,92 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/codingbrain/clix.go/gen"
+)
+
+// fishQuote quotes a string for fish, where only \ and ' are escaped
+func fishQuote(str string) string {
+	str = strings.Replace(str, `\`, `\\`, -1)
+	return "'" + strings.Replace(str, "'", `\'`, -1) + "'"
+}
+
+func genFish(w *gen.Writer, program string, nodes []*cmdNode) {
+	fn := "__" + identifier(program) + "_cmd"
+	root := nodes[0].id
+	w.Writeln("# fish completion for %s", program)
+	w.Writeln("function %s", fn)
+	w1 := w.Indent()
+	w1.Writeln("set -l cmd %s", root)
+	w1.Writeln("for word in (commandline -opc)[2..-1]")
+	w2 := w1.Indent()
+	w2.Writeln(`switch "$cmd:$word"`)
+	w3 := w2.Indent()
+	for _, n := range nodes {
+		for _, t := range n.transitions() {
+			var patterns []string
+			for _, name := range t.names {
+				patterns = append(patterns, fishQuote(n.id+":"+name))
+			}
+			w3.Writeln("case %s", strings.Join(patterns, " "))
+			w3.Indent().Writeln("set cmd %s", t.id)
+		}
+	}
+	w2.Writeln("end")
+	w1.Writeln("end")
+	w1.Writeln("echo $cmd")
+	w.Writeln("end")
+	w.Writeln("")
+
+	for _, n := range nodes {
+		cond := fishQuote("test (" + fn + ") = " + n.id)
+		for _, sub := range VisibleCommands(n.cmd) {
+			line := "complete -c " + program + " -n " + cond + " -f -a " +
+				fishQuote(strings.Join(cmdNames(sub), " "))
+			if desc := Summary(sub.Desc); desc != "" {
+				line += " -d " + fishQuote(desc)
+			}
+			w.Writeln("%s", line)
+		}
+		for _, opt := range n.opts {
+			line := "complete -c " + program + " -n " + cond
+			long, short := optNames(opt)
+			for _, name := range long {
+				line += " -l " + fishQuote(name)
+			}
+			for _, name := range short {
+				line += " -s " + fishQuote(name)
+			}
+			if opt.ExpectValue() {
+				line += " -r"
+			}
+			if desc := Summary(opt.Desc); desc != "" {
+				line += " -d " + fishQuote(desc)
+			}
+			w.Writeln("%s", line)
+		}
+	}
+}
+
+func genFishDynamic(w *gen.Writer, program string) {
+	fn := "__" + identifier(program) + "_complete"
+	w.Writeln("# fish completion for %s", program)
+	w.Writeln("function %s", fn)
+	w1 := w.Indent()
+	w1.Writeln("set -l words (commandline -opc)[2..-1] (commandline -ct)")
+	w1.Writeln("%s %s $words 2>/dev/null | while read -l line", program, CompleteCmdName)
+	w2 := w1.Indent()
+	w2.Writeln("switch $line")
+	w3 := w2.Indent()
+	w3.Writeln("case %s", fishQuote(":"+DirectiveFiles))
+	w3.Indent().Writeln("__fish_complete_path (commandline -ct)")
+	w3.Writeln("case ':*'")
+	w3.Writeln("case '*'")
+	w3.Indent().Writeln("echo $line")
+	w2.Writeln("end")
+	w1.Writeln("end")
+	w.Writeln("end")
+	w.Writeln("")
+	w.Writeln("complete -c %s -f -a '(%s)'", program, fn)
+}
@@ -0,0 +1,120 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/codingbrain/clix.go/gen"
+)
+
+// describeItem formats an item for _describe, colons in name must be escaped
+func describeItem(name, desc string) string {
+	item := strings.Replace(name, ":", `\:`, -1)
+	if desc = Summary(desc); desc != "" {
+		item += ":" + desc
+	}
+	return singleQuote(item)
+}
+
+func genZsh(w *gen.Writer, program string, nodes []*cmdNode) {
+	fn := "_" + identifier(program)
+	root := nodes[0].id
+	w.Writeln("#compdef %s", program)
+	w.Writeln("")
+	w.Writeln("%s() {", fn)
+	w1 := w.Indent()
+	w1.Writeln("local cmd=%s i", root)
+	w1.Writeln("local -a opts cmds")
+	w1.Writeln("for ((i = 2; i < CURRENT; i++)); do")
+	w2 := w1.Indent()
+	w2.Writeln(`case "$cmd:${words[i]}" in`)
+	w3 := w2.Indent()
+	for _, n := range nodes {
+		for _, t := range n.transitions() {
+			var patterns []string
+			for _, name := range t.names {
+				patterns = append(patterns, singleQuote(n.id+":"+name))
+			}
+			w3.Writeln("%s) cmd=%s ;;", strings.Join(patterns, "|"), t.id)
+		}
+	}
+	w2.Writeln("esac")
+	w1.Writeln("done")
+	w1.Writeln(`case "$cmd" in`)
+	w2 = w1.Indent()
+	for _, n := range nodes {
+		var opts, cmds []string
+		for _, opt := range n.opts {
+			for _, word := range OptionWords(opt) {
+				opts = append(opts, describeItem(word, opt.Desc))
+			}
+		}
+		for _, sub := range VisibleCommands(n.cmd) {
+			for _, name := range cmdNames(sub) {
+				cmds = append(cmds, describeItem(name, sub.Desc))
+			}
+		}
+		w2.Writeln("%s)", n.id)
+		w3 := w2.Indent()
+		if len(opts) > 0 {
+			w3.Writeln("opts=(%s)", strings.Join(opts, " "))
+		}
+		if len(cmds) > 0 {
+			w3.Writeln("cmds=(%s)", strings.Join(cmds, " "))
+		}
+		w3.Writeln(";;")
+	}
+	w1.Writeln("esac")
+	w1.Writeln(`if [[ "${words[CURRENT]}" == -* ]]; then`)
+	w1.Indent().Writeln("_describe -t options option opts")
+	w1.Writeln("elif (( ${#cmds} )); then")
+	w1.Indent().Writeln("_describe -t commands command cmds")
+	w1.Writeln("else")
+	w1.Indent().Writeln("_files")
+	w1.Writeln("fi")
+	w.Writeln("}")
+	w.Writeln("")
+	w.Writeln(`if [ "$funcstack[1]" = "%s" ]; then`, fn)
+	w.Indent().Writeln(`%s "$@"`, fn)
+	w.Writeln("else")
+	w.Indent().Writeln("compdef %s %s", fn, program)
+	w.Writeln("fi")
+}
+
+func genZshDynamic(w *gen.Writer, program string) {
+	fn := "_" + identifier(program)
+	w.Writeln("#compdef %s", program)
+	w.Writeln("")
+	w.Writeln("%s() {", fn)
+	w1 := w.Indent()
+	w1.Writeln(`local line value directive=""`)
+	w1.Writeln("local -a cands")
+	w1.Writeln(`%s %s "${(@)words[2,CURRENT]}" 2>/dev/null | while IFS='' read -r line; do`, program, CompleteCmdName)
+	w2 := w1.Indent()
+	w2.Writeln(`case "$line" in`)
+	w3 := w2.Indent()
+	w3.Writeln(`:*) directive="${line#:}" ;;`)
+	w3.Writeln("*)")
+	w4 := w3.Indent()
+	w4.Writeln(`value="${${line%%%%$'\t'*}//:/\\:}"`)
+	w4.Writeln(`if [[ "$line" == *$'\t'* ]]; then`)
+	w4.Indent().Writeln(`cands+=("$value:${line#*$'\t'}")`)
+	w4.Writeln("else")
+	w4.Indent().Writeln(`cands+=("$value")`)
+	w4.Writeln("fi")
+	w4.Writeln(";;")
+	w2.Writeln("esac")
+	w1.Writeln("done")
+	w1.Writeln("if (( ${#cands} )); then")
+	w1.Indent().Writeln("_describe -t values value cands")
+	w1.Writeln("fi")
+	w1.Writeln(`if [ "$directive" = %s ]; then`, DirectiveFiles)
+	w1.Indent().Writeln("_files")
+	w1.Writeln("fi")
+	w.Writeln("}")
+	w.Writeln("")
+	w.Writeln(`if [ "$funcstack[1]" = "%s" ]; then`, fn)
+	w.Indent().Writeln(`%s "$@"`, fn)
+	w.Writeln("else")
+	w.Indent().Writeln("compdef %s %s", fn, program)
+	w.Writeln("fi")
+}
@@ -1,6 +1,8 @@
 package golang
 
 import (
+	"fmt"
+
 	"github.com/codingbrain/clix.go/flag"
 	"github.com/codingbrain/clix.go/gen"
 )
@@ -80,6 +82,11 @@ func (b *ClixBackend) GenerateCode(def *flag.CliDef, w *gen.Writer) error {
 	return nil
 }
 
+type field struct {
+	name  string
+	value string
+}
+
 func pad(str string, minLen int) string {
 	diff := minLen - len(str)
 	for i := 0; i < diff; i++ {
@@ -88,28 +95,56 @@ func pad(str string, minLen int) string {
 	return str
 }
 
-func printCommand(w *gen.Writer, prefix string, cmd *flag.Command) {
+// printFields prints single line fields aligned like gofmt does
+func printFields(w *gen.Writer, fields []field) {
 	padding := 0
-	if len(cmd.Alias) > 0 {
-		padding = 1
+	for _, f := range fields {
+		if l := len(f.name) + 2; l > padding {
+			padding = l
+		}
 	}
-	if cmd.Example != "" {
-		padding = 3
+	for _, f := range fields {
+		w.Writeln("%s%s,", pad(f.name+":", padding), f.value)
 	}
-	padding += 6
+}
 
-	w.Writeln(prefix + "&flag.Command{")
-	w1 := w.Indent()
-	w1.Writeln(pad("Name:", padding)+"%#v,", cmd.Name)
+func printCommand(w *gen.Writer, prefix string, cmd *flag.Command) {
+	var fields []field
+	fields = append(fields, field{"Name", fmt.Sprintf("%#v", cmd.Name)})
 	if len(cmd.Alias) > 0 {
-		w1.Writeln(pad("Alias:", padding)+"%#v,", cmd.Alias)
+		fields = append(fields, field{"Alias", fmt.Sprintf("%#v", cmd.Alias)})
 	}
 	if cmd.Desc != "" {
-		w1.Writeln(pad("Desc:", padding)+"%#v,", cmd.Desc)
+		fields = append(fields, field{"Desc", fmt.Sprintf("%#v", cmd.Desc)})
 	}
 	if cmd.Example != "" {
-		w1.Writeln(pad("Example:", padding)+"%#v,", cmd.Example)
+		fields = append(fields, field{"Example", fmt.Sprintf("%#v", cmd.Example)})
 	}
+	if len(cmd.Exclusive) > 0 {
+		fields = append(fields, field{"Exclusive", fmt.Sprintf("%#v", cmd.Exclusive)})
+	}
+	if len(cmd.Requires) > 0 {
+		fields = append(fields, field{"Requires", fmt.Sprintf("%#v", cmd.Requires)})
+	}
+	if len(cmd.AtLeastOne) > 0 {
+		fields = append(fields, field{"AtLeastOne", fmt.Sprintf("%#v", cmd.AtLeastOne)})
+	}
+	if cmd.Hidden {
+		fields = append(fields, field{"Hidden", "true"})
+	}
+	if cmd.Deprecated != "" {
+		fields = append(fields, field{"Deprecated", fmt.Sprintf("%#v", cmd.Deprecated)})
+	}
+	if cmd.ReplacedBy != "" {
+		fields = append(fields, field{"ReplacedBy", fmt.Sprintf("%#v", cmd.ReplacedBy)})
+	}
+	if cmd.StopAtArgs {
+		fields = append(fields, field{"StopAtArgs", "true"})
+	}
+
+	w.Writeln(prefix + "&flag.Command{")
+	w1 := w.Indent()
+	printFields(w1, fields)
 	// TODO Tags
 	if len(cmd.Options) > 0 {
 		printOptions(w1, "Options: ", cmd.Options)
@@ -132,41 +167,77 @@ func printOptions(w *gen.Writer, prefix string, opts []*flag.Option) {
 	w.Writeln(prefix + "[]*flag.Option{")
 	w1 := w.Indent()
 	for _, opt := range opts {
-		padding := 0
+		var fields []field
+		fields = append(fields, field{"Name", fmt.Sprintf("%#v", opt.Name)})
 		if len(opt.Alias) > 0 {
-			padding = 1
-		}
-		if opt.Example != "" || opt.Default != nil {
-			padding = 3
-		}
-		if opt.Required {
-			padding = 4
-		}
-		padding += 6
-		w1.Writeln("&flag.Option{")
-		w2 := w1.Indent()
-		w2.Writeln(pad("Name:", padding)+"%#v,", opt.Name)
-		if len(opt.Alias) > 0 {
-			w2.Writeln(pad("Alias:", padding)+"%#v,", opt.Alias)
+			fields = append(fields, field{"Alias", fmt.Sprintf("%#v", opt.Alias)})
 		}
 		if opt.Desc != "" {
-			w2.Writeln(pad("Desc:", padding)+"%#v,", opt.Desc)
+			fields = append(fields, field{"Desc", fmt.Sprintf("%#v", opt.Desc)})
 		}
 		if opt.Example != "" {
-			w2.Writeln(pad("Example:", padding)+"%#v,", opt.Example)
+			fields = append(fields, field{"Example", fmt.Sprintf("%#v", opt.Example)})
 		}
-		if opt.Type != "" {
-			w2.Writeln(pad("Type:", padding)+"%#v,", opt.Type)
+		if typ := opt.Type; typ != "" {
+			if opt.SubType != "" {
+				// Type is split by Normalize
+				typ += "/" + opt.SubType
+			}
+			fields = append(fields, field{"Type", fmt.Sprintf("%#v", typ)})
 		}
 		if opt.List {
-			w2.Writeln(pad("List:", padding) + "true,")
+			fields = append(fields, field{"List", "true"})
 		}
 		if opt.Required {
-			w2.Writeln(pad("Required:", padding) + "true,")
+			fields = append(fields, field{"Required", "true"})
 		}
 		if opt.Default != nil {
-			w2.Writeln(pad("Default:", padding)+"%#v,", opt.Default)
+			fields = append(fields, field{"Default", fmt.Sprintf("%#v", opt.Default)})
+		}
+		if opt.DefaultDesc != "" {
+			fields = append(fields, field{"DefaultDesc", fmt.Sprintf("%#v", opt.DefaultDesc)})
+		}
+		if opt.Env != "" {
+			fields = append(fields, field{"Env", fmt.Sprintf("%#v", opt.Env)})
+		}
+		if opt.Local {
+			fields = append(fields, field{"Local", "true"})
+		}
+		if opt.Hidden {
+			fields = append(fields, field{"Hidden", "true"})
+		}
+		if opt.Deprecated != "" {
+			fields = append(fields, field{"Deprecated", fmt.Sprintf("%#v", opt.Deprecated)})
 		}
+		if opt.ReplacedBy != "" {
+			fields = append(fields, field{"ReplacedBy", fmt.Sprintf("%#v", opt.ReplacedBy)})
+		}
+		if opt.AllowDashValue {
+			fields = append(fields, field{"AllowDashValue", "true"})
+		}
+		if opt.NestedKeys {
+			fields = append(fields, field{"NestedKeys", "true"})
+		}
+		if len(opt.Choices) > 0 {
+			fields = append(fields, field{"Choices", fmt.Sprintf("%#v", opt.Choices)})
+		}
+		if opt.Min != nil {
+			fields = append(fields, field{"Min", fmt.Sprintf("%#v", opt.Min)})
+		}
+		if opt.Max != nil {
+			fields = append(fields, field{"Max", fmt.Sprintf("%#v", opt.Max)})
+		}
+		if opt.Pattern != "" {
+			fields = append(fields, field{"Pattern", fmt.Sprintf("%#v", opt.Pattern)})
+		}
+		if opt.MinCount != 0 {
+			fields = append(fields, field{"MinCount", fmt.Sprintf("%d", opt.MinCount)})
+		}
+		if opt.MaxCount != 0 {
+			fields = append(fields, field{"MaxCount", fmt.Sprintf("%d", opt.MaxCount)})
+		}
+		w1.Writeln("&flag.Option{")
+		printFields(w1.Indent(), fields)
 		// TODO Tags
 		w1.Writeln("},")
 	}
@@ -1,5 +1,5 @@
 OUTDIR=_out
-PKGS="clix flag term exts/bind exts/help"
+PKGS="clix flag term exts/alias exts/bind exts/complete exts/config exts/deprecate exts/help exts/plugin"
 
 env-setup() {
     mkdir -p $OUTDIR